@@ -0,0 +1,43 @@
+package ginx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
+)
+
+// PrincipalContextKey is the gin context key of the principal returned by the Authenticator.
+const PrincipalContextKey = "ginx.principal"
+
+// Authenticator verifies the credentials of a security scheme declared on the matched operation, input.Scopes are
+// the scopes required by the operation. The returned principal is stored in the gin context once the security
+// requirements of the operation are satisfied.
+type Authenticator func(c *gin.Context, input *openapi3filter.AuthenticationInput) (principal interface{}, err error)
+
+// Principal returns the principal of the authenticated request, or nil if it is not authenticated.
+func Principal(c *gin.Context) interface{} {
+	principal, _ := c.Get(PrincipalContextKey)
+	return principal
+}
+
+// authenticationFunc keeps the principal of the last authenticated scheme, which belongs to the satisfied security
+// requirement since they are checked in order until one is satisfied.
+func (o *ValidatorOptions) authenticationFunc(c *gin.Context, principal *interface{}) openapi3filter.AuthenticationFunc {
+	if o.Authenticators == nil {
+		return openapi3filter.NoopAuthenticationFunc
+	}
+	return func(_ context.Context, input *openapi3filter.AuthenticationInput) error {
+		authenticator, exists := o.Authenticators[input.SecuritySchemeName]
+		if !exists {
+			return input.NewError(fmt.Errorf("security scheme=%s has no authenticator", input.SecuritySchemeName))
+		}
+		p, err := authenticator(c, input)
+		if err != nil {
+			return input.NewError(err)
+		}
+		*principal = p
+		return nil
+	}
+}
@@ -0,0 +1,181 @@
+package ginx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
+)
+
+type authAPI struct {
+	spec *Spec
+}
+
+func (a authAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/auth").Security("bearer")
+	handler := func(ctx *gin.Context) {
+		principal, _ := Principal(ctx).(string)
+		ctx.String(http.StatusOK, principal)
+	}
+	rg.Get("/bearer").To(handler).Doc().
+		Response("200", TextResponseBody("user"), "success")
+	rg.Get("/either").To(handler).Doc().
+		Security("bearer", "admin").
+		Security("apiKey").
+		Response("200", TextResponseBody("user"), "success")
+	rg.Get("/public").To(handler).Doc().
+		NoSecurity().
+		Response("200", TextResponseBody("user"), "success")
+	return rg
+}
+
+func TestSpec_UseValidatorWithAuthenticators(t *testing.T) {
+	spec := NewSpec("auth description", "1.0.0", "auth")
+	spec.DefineSecurityScheme("bearer", HTTPBearer("JWT"))
+	spec.DefineSecurityScheme("apiKey", APIKey(ParamHeader, "X-API-Key"))
+
+	var validationErr error
+	g := gin.New()
+	spec.UseSwaggerUI(g, "/apidoc")
+	spec.UseValidatorWithOptions(g, func(ctx *gin.Context, err error) {
+		validationErr = err
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+		}
+	}, &ValidatorOptions{
+		Authenticators: map[string]Authenticator{
+			"bearer": func(c *gin.Context, input *openapi3filter.AuthenticationInput) (interface{}, error) {
+				switch c.GetHeader("Authorization") {
+				case "Bearer admin":
+					return "admin", nil
+				case "Bearer user":
+					if len(input.Scopes) > 0 {
+						return nil, errors.New("insufficient scopes")
+					}
+					return "user", nil
+				}
+				return nil, errors.New("invalid token")
+			},
+			"apiKey": func(c *gin.Context, input *openapi3filter.AuthenticationInput) (interface{}, error) {
+				if c.GetHeader("X-API-Key") == "key" {
+					return "service", nil
+				}
+				return nil, errors.New("invalid api key")
+			},
+		},
+	})
+	spec.AddAPI(g, authAPI{spec: spec})
+
+	for _, tc := range []struct {
+		path      string
+		header    string
+		value     string
+		code      int
+		principal string
+	}{
+		{"/auth/bearer", "Authorization", "Bearer user", http.StatusOK, "user"},
+		{"/auth/bearer", "Authorization", "Bearer invalid", http.StatusUnauthorized, ""},
+		{"/auth/bearer", "", "", http.StatusUnauthorized, ""},
+		{"/auth/either", "Authorization", "Bearer admin", http.StatusOK, "admin"},
+		{"/auth/either", "Authorization", "Bearer user", http.StatusUnauthorized, ""},
+		{"/auth/either", "X-API-Key", "key", http.StatusOK, "service"},
+		{"/auth/public", "", "", http.StatusOK, ""},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.header != "" {
+			req.Header.Set(tc.header, tc.value)
+		}
+		g.ServeHTTP(w, req)
+		assertEqual(t, w.Code, tc.code)
+		if tc.code == http.StatusOK {
+			assertEqual(t, w.Body.String(), tc.principal)
+		} else {
+			var securityErr *openapi3filter.SecurityRequirementsError
+			assertTrue(t, errors.As(validationErr, &securityErr))
+		}
+	}
+}
+
+type bothAuthAPI struct {
+	spec *Spec
+}
+
+func (a bothAuthAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/auth")
+	rg.Get("/both").To(func(ctx *gin.Context) {
+		principal, _ := Principal(ctx).(string)
+		ctx.String(http.StatusOK, principal)
+	}).Doc().
+		Response("200", TextResponseBody("user"), "success")
+	// the DSL only declares alternatives, both schemes are required by the same requirement here
+	a.spec.Root().Paths.Value("/auth/both").Get.Security = &openapi3.SecurityRequirements{
+		openapi3.NewSecurityRequirement().Authenticate("apiKey").Authenticate("bearer"),
+	}
+	return rg
+}
+
+func TestSpec_UseValidatorWithPartialAuthentication(t *testing.T) {
+	spec := NewSpec("auth description", "1.0.0", "auth")
+	spec.DefineSecurityScheme("bearer", HTTPBearer("JWT"))
+	spec.DefineSecurityScheme("apiKey", APIKey(ParamHeader, "X-API-Key"))
+
+	var validationErr error
+	var principal interface{}
+	g := gin.New()
+	spec.UseValidatorWithOptions(g, func(ctx *gin.Context, err error) {
+		validationErr = err
+		principal = Principal(ctx)
+	}, &ValidatorOptions{
+		Authenticators: map[string]Authenticator{
+			"bearer": func(c *gin.Context, input *openapi3filter.AuthenticationInput) (interface{}, error) {
+				if c.GetHeader("Authorization") == "Bearer user" {
+					return "user", nil
+				}
+				return nil, errors.New("invalid token")
+			},
+			"apiKey": func(c *gin.Context, input *openapi3filter.AuthenticationInput) (interface{}, error) {
+				if c.GetHeader("X-API-Key") == "key" {
+					return "service", nil
+				}
+				return nil, errors.New("invalid api key")
+			},
+		},
+	})
+	spec.AddAPI(g, bothAuthAPI{spec: spec})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/auth/both", nil)
+	req.Header.Set("X-API-Key", "key")
+	g.ServeHTTP(w, req)
+	var securityErr *openapi3filter.SecurityRequirementsError
+	assertTrue(t, errors.As(validationErr, &securityErr))
+	assertNil(t, principal)
+	assertEqual(t, w.Code, http.StatusUnauthorized)
+
+	validationErr = nil
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/auth/both", nil)
+	req.Header.Set("X-API-Key", "key")
+	req.Header.Set("Authorization", "Bearer user")
+	g.ServeHTTP(w, req)
+	assertNil(t, validationErr)
+	assertEqual(t, w.Body.String(), "user")
+}
+
+func TestSpec_UseValidatorWithUndefinedAuthenticator(t *testing.T) {
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	NewSpec("", "1.0.0", "").UseValidatorWithOptions(gin.New(), func(ctx *gin.Context, err error) {}, &ValidatorOptions{
+		Authenticators: map[string]Authenticator{
+			"bearer": func(c *gin.Context, input *openapi3filter.AuthenticationInput) (interface{}, error) {
+				return nil, nil
+			},
+		},
+	})
+}
@@ -0,0 +1,94 @@
+package ginx
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bodyUser struct {
+	Name string `json:"name" xml:"name" doc:"required"`
+}
+
+type bodyAPI struct {
+	spec *Spec
+}
+
+func (a bodyAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/body")
+	rg.
+		Post("/users").
+		To(func(ctx *gin.Context) {
+			ctx.Data(http.StatusCreated, "application/vnd.users+json", []byte(`{"name":"one"}`))
+		}).
+		Doc().
+		RequestBody(RequestBody("application/vnd.users+json", bodyUser{Name: "one"}).
+			Examples(map[string]interface{}{"one": bodyUser{Name: "one"}, "two": bodyUser{Name: "two"}})).
+		Response("201", ResponseBody("application/vnd.users+json", bodyUser{Name: "one"}), "created")
+	rg.
+		Put("/avatar").
+		To(func(ctx *gin.Context) {
+			ctx.Data(http.StatusOK, "image/png", []byte{0x89, 'P', 'N', 'G'})
+		}).
+		Doc().
+		RequestBody(RequestBody("application/octet-stream", nil)).
+		Response("200", ResponseBody("image/png", []byte{}), "avatar")
+	rg.
+		Post("/upload").
+		To(func(ctx *gin.Context) {}).
+		Doc().
+		RequestBody(MultiPartFormRequestBody(struct {
+			Name string `form:"name"`
+			Meta string `form:"meta"`
+		}{}).Encoding("meta", "application/json")).
+		Response("200", PDFResponseBody(), "report")
+	return rg
+}
+
+func TestRequestAndResponseBody(t *testing.T) {
+	spec := NewSpec("body description", "1.0.0", "body")
+	g := gin.New()
+	spec.UseValidator(g, func(ctx *gin.Context, err error) {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+	})
+	var errs []error
+	spec.UseResponseValidator(g, ResponseValidationLog, func(ctx *gin.Context, err error) {
+		errs = append(errs, err)
+	})
+	assertNil(t, spec.AddAPI(g, bodyAPI{spec: spec}))
+	assertNil(t, spec.ValidateSpec(context.Background()))
+
+	users := spec.Root().Paths.Value("/body/users").Post
+	content := users.RequestBody.Value.Content.Get("application/vnd.users+json")
+	assertEqual(t, content.Schema.Ref, "#/components/schemas/bodyUser")
+	assertTrue(t, content.Example == nil)
+	assertEqual(t, content.Examples["two"].Value.Value.(map[string]interface{})["name"], "two")
+	assertNotNil(t, users.Responses.Status(http.StatusCreated).Value.Content.Get("application/vnd.users+json").Example)
+	avatar := spec.Root().Paths.Value("/body/avatar").Put
+	assertEqual(t, avatar.RequestBody.Value.Content.Get("application/octet-stream").Schema.Value.Format, "binary")
+	assertEqual(t, avatar.Responses.Status(http.StatusOK).Value.Content.Get("image/png").Schema.Value.Format, "binary")
+	upload := spec.Root().Paths.Value("/body/upload").Post
+	assertEqual(t, upload.RequestBody.Value.Content.Get("multipart/form-data").Encoding["meta"].ContentType, "application/json")
+	assertEqual(t, upload.Responses.Status(http.StatusOK).Value.Content.Get("application/pdf").Schema.Value.Format, "binary")
+
+	serve := func(method, url, contentType, body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		g.ServeHTTP(w, req)
+		return w.Code
+	}
+	assertEqual(t, serve(http.MethodPost, "/body/users", "application/vnd.users+json", `{"name":"one"}`), http.StatusCreated)
+	assertEqual(t, serve(http.MethodPost, "/body/users", "application/vnd.users+json", `{}`), http.StatusBadRequest)
+	assertEqual(t, serve(http.MethodPut, "/body/avatar", "application/octet-stream", "\x89PNG"), http.StatusOK)
+	assertEqual(t, len(errs), 0)
+
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	(&docResponse{}).Examples(map[string]interface{}{"one": 1})
+}
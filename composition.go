@@ -0,0 +1,134 @@
+package ginx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	compositionOneOf = "oneOf"
+	compositionAnyOf = "anyOf"
+	compositionAllOf = "allOf"
+)
+
+// docComposition is a prototype of a polymorphic payload, it can be passed wherever a prototype is expected, e.g.
+// JSONRequestBody(OneOf(Card{}, Bank{})).
+type docComposition struct {
+	keyword       string
+	prototypes    []interface{}
+	discriminator string
+	mapping       map[string]interface{}
+}
+
+// OneOf documents a payload which matches exactly one of the prototypes.
+func OneOf(prototypes ...interface{}) *docComposition {
+	return newDocComposition(compositionOneOf, prototypes)
+}
+
+// AnyOf documents a payload which matches at least one of the prototypes.
+func AnyOf(prototypes ...interface{}) *docComposition {
+	return newDocComposition(compositionAnyOf, prototypes)
+}
+
+// AllOf documents a payload which matches all of the prototypes.
+func AllOf(prototypes ...interface{}) *docComposition {
+	return newDocComposition(compositionAllOf, prototypes)
+}
+
+func newDocComposition(keyword string, prototypes []interface{}) *docComposition {
+	if len(prototypes) == 0 {
+		panic(fmt.Sprintf("%s must have at least one prototype", keyword))
+	}
+	return &docComposition{keyword: keyword, prototypes: prototypes}
+}
+
+// Discriminator names the property which tells the prototype of the payload, mapping maps its values to the
+// prototypes. When mapping is nil the values are the component names of the prototypes.
+func (d *docComposition) Discriminator(propertyName string, mapping map[string]interface{}) *docComposition {
+	if d.keyword == compositionAllOf {
+		panic("discriminator is only supported by oneOf and anyOf")
+	}
+	d.discriminator = propertyName
+	d.mapping = mapping
+	return d
+}
+
+// MarshalJSON renders the first prototype, so that it is the example of the payload.
+func (d *docComposition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.prototypes[0])
+}
+
+func (d *docComposition) schemaRef(s *Spec) *openapi3.SchemaRef {
+	sch := &openapi3.Schema{}
+	refs := make(openapi3.SchemaRefs, len(d.prototypes))
+	for i, prototype := range d.prototypes {
+		refs[i] = s.extractSchema(prototype)
+	}
+	setComposition(sch, d.keyword, refs)
+	if d.discriminator != "" {
+		sch.Discriminator = &openapi3.Discriminator{PropertyName: d.discriminator}
+		if d.mapping != nil {
+			sch.Discriminator.Mapping = make(map[string]string)
+			for value, prototype := range d.mapping {
+				sch.Discriminator.Mapping[value] = mustComponentRef(s.extractSchema(prototype), prototype)
+			}
+		}
+		completeDiscriminator(sch)
+	}
+	return openapi3.NewSchemaRef("", sch)
+}
+
+func setComposition(sch *openapi3.Schema, keyword string, refs openapi3.SchemaRefs) {
+	switch keyword {
+	case compositionOneOf:
+		sch.OneOf = refs
+	case compositionAnyOf:
+		sch.AnyOf = refs
+	case compositionAllOf:
+		sch.AllOf = refs
+	}
+}
+
+// completeDiscriminator maps the component names of the alternatives when no mapping is given, the validator only
+// picks the alternative by the discriminator through the mapping.
+func completeDiscriminator(sch *openapi3.Schema) {
+	alternatives := sch.OneOf
+	if len(alternatives) == 0 {
+		alternatives = sch.AnyOf
+	}
+	if len(alternatives) == 0 {
+		panic(fmt.Sprintf("discriminator=%s must come with oneOf or anyOf", sch.Discriminator.PropertyName))
+	}
+	if len(sch.Discriminator.Mapping) > 0 {
+		return
+	}
+	sch.Discriminator.Mapping = make(map[string]string)
+	for _, ref := range alternatives {
+		name := strings.TrimPrefix(mustComponentRef(ref, nil), "#/components/schemas/")
+		sch.Discriminator.Mapping[name] = ref.Ref
+	}
+}
+
+func mustComponentRef(ref *openapi3.SchemaRef, prototype interface{}) string {
+	if ref == nil || !strings.HasPrefix(ref.Ref, "#/components/schemas/") {
+		panic(fmt.Sprintf("prototype=%+v of discriminator must be a named struct", prototype))
+	}
+	return ref.Ref
+}
+
+// compositionTag applies the oneOf(a;b) and anyOf(a;b) doc tags of an interface field, each item is the key of a
+// prototype defined by DefineRef.
+func (s *Spec) compositionTag(sch *openapi3.Schema, keyword, val string) {
+	var refs openapi3.SchemaRefs
+	for _, key := range strings.Split(val, ";") {
+		prototype, exists := s.refs[key]
+		if !exists {
+			panic(fmt.Sprintf("docRef key=%s of %s is not defined, must call DefineRef first", key, keyword))
+		}
+		refs = append(refs, s.extractSchema(prototype))
+	}
+	setComposition(sch, keyword, refs)
+}
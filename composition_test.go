@@ -0,0 +1,113 @@
+package ginx
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type cardPayment struct {
+	Type   string `json:"type" doc:"required"`
+	Number string `json:"number" doc:"required minLength(4)"`
+}
+
+type bankPayment struct {
+	Type    string `json:"type" doc:"required"`
+	Account string `json:"account" doc:"required"`
+}
+
+type order struct {
+	ID      string      `json:"id" doc:"required"`
+	Payment interface{} `json:"payment" doc:"required oneOf(#card;#bank) discriminator(type)"`
+}
+
+type paymentAPI struct {
+	spec *Spec
+}
+
+func (a paymentAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/payments")
+	handler := func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "success")
+	}
+	rg.Post("").To(handler).Doc().
+		RequestBody(JSONRequestBody(OneOf(cardPayment{Type: "card", Number: "4242"}, bankPayment{Type: "bank", Account: "001"}).
+			Discriminator("type", map[string]interface{}{"card": cardPayment{}, "bank": bankPayment{}}))).
+		Response("200", TextResponseBody("success"), "success")
+	rg.Post("/orders").To(handler).Doc().
+		RequestBody(JSONRequestBody(order{ID: "1", Payment: cardPayment{Type: "cardPayment", Number: "4242"}})).
+		Response("200", TextResponseBody("success"), "success")
+	rg.Post("/any").To(handler).Doc().
+		RequestBody(JSONRequestBody(AnyOf(cardPayment{Type: "card", Number: "4242"}, bankPayment{Type: "bank", Account: "001"}))).
+		Response("200", TextResponseBody("success"), "success")
+	return rg
+}
+
+func TestOneOf(t *testing.T) {
+	spec := NewSpec("payment description", "1.0.0", "payment")
+	spec.DefineRef("#card", cardPayment{})
+	spec.DefineRef("#bank", bankPayment{})
+	g := gin.New()
+	spec.UseValidator(g, ProblemValidationErrorHandler)
+	assertNil(t, spec.AddAPI(g, paymentAPI{spec: spec}))
+	assertNil(t, spec.ValidateSpec(context.Background()))
+
+	body := spec.Root().Paths.Value("/payments").Post.RequestBody.Value.Content.Get("application/json")
+	assertEqual(t, len(body.Schema.Value.OneOf), 2)
+	assertEqual(t, body.Schema.Value.Discriminator.PropertyName, "type")
+	assertEqual(t, body.Schema.Value.Discriminator.Mapping["card"], "#/components/schemas/cardPayment")
+	assertEqual(t, body.Example.(map[string]interface{})["number"], "4242")
+
+	payment := spec.Root().Components.Schemas["order"].Value.Properties["payment"].Value
+	assertEqual(t, len(payment.OneOf), 2)
+	assertEqual(t, payment.Discriminator.Mapping["bankPayment"], "#/components/schemas/bankPayment")
+
+	for _, tc := range []struct {
+		path string
+		body string
+		code int
+	}{
+		{"/payments", `{"type": "card", "number": "4242"}`, http.StatusOK},
+		{"/payments", `{"type": "bank", "account": "001"}`, http.StatusOK},
+		{"/payments", `{"type": "card", "number": "42"}`, http.StatusBadRequest},
+		{"/payments", `{"type": "cash"}`, http.StatusBadRequest},
+		{"/payments/orders", `{"id": "1", "payment": {"type": "bankPayment", "account": "001"}}`, http.StatusOK},
+		{"/payments/orders", `{"id": "1", "payment": {"type": "bankPayment", "number": "4242"}}`, http.StatusBadRequest},
+		{"/payments/any", `{"type": "bank", "account": "001", "number": "4242"}`, http.StatusOK},
+		{"/payments/any", `{"type": "bank"}`, http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		g.ServeHTTP(w, req)
+		assertEqual(t, w.Code, tc.code)
+	}
+}
+
+func TestCompositionMisuse(t *testing.T) {
+	for _, fn := range []func(){
+		func() { OneOf() },
+		func() { AllOf(cardPayment{}).Discriminator("type", nil) },
+		func() {
+			NewSpec("", "1.0.0", "").extractSchema(OneOf(struct {
+				Type string `json:"type" doc:"required"`
+			}{}).Discriminator("type", nil))
+		},
+		func() {
+			NewSpec("", "1.0.0", "").extractSchema(struct {
+				Name string `json:"name" doc:"oneOf(#card)"`
+			}{})
+		},
+	} {
+		func() {
+			defer func() {
+				assertTrue(t, recover() != nil)
+			}()
+			fn()
+		}()
+	}
+}
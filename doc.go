@@ -1,22 +1,29 @@
 package ginx
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"net/http"
+	"path"
 	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
 )
 
 const (
 	ParamQuery  = "query"
 	ParamPath   = "path"
 	ParamHeader = "header"
+	ParamCookie = "cookie"
 
 	RootTag = "doc"
 
@@ -32,12 +39,58 @@ const (
 	DocTagFieldMinimum         = "minimum"
 	DocTagFieldStringMaxLength = "maxLength"
 	DocTagFieldStringMinLength = "minLength"
+	DocTagFieldDefault         = "default"
+	DocTagFieldExample         = "example"
+	DocTagFieldReadOnly        = "readOnly"
+	DocTagFieldWriteOnly       = "writeOnly"
+	DocTagFieldDeprecated      = "deprecated"
+	DocTagFieldMultipleOf      = "multipleOf"
+	DocTagFieldExclusiveMin    = "exclusiveMinimum"
+	DocTagFieldExclusiveMax    = "exclusiveMaximum"
+	DocTagFieldUniqueItems     = "uniqueItems"
+	DocTagFieldMinProperties   = "minProperties"
+	DocTagFieldMaxProperties   = "maxProperties"
+	DocTagFieldTitle           = "title"
+	DocTagFieldOneOf           = "oneOf"
+	DocTagFieldAnyOf           = "anyOf"
+	DocTagFieldDiscriminator   = "discriminator"
 )
 
+// DocRoot is the OpenAPI document of DefaultSpec, kept for backward compatibility.
 var DocRoot *openapi3.T
 
-func Init(description, version, title string) {
-	DocRoot = &openapi3.T{
+// Refs holds the doc tag references of DefaultSpec, kept for backward compatibility.
+var Refs = make(map[string]interface{})
+
+// DefaultSpec is the Spec used by the package level functions.
+var DefaultSpec = &Spec{refs: Refs}
+
+// Spec owns an OpenAPI document together with its doc tag references and swagger UI path,
+// so that one process can serve several independent APIs.
+type Spec struct {
+	root              *openapi3.T
+	refs              map[string]interface{}
+	swaggerPathPrefix string
+	typedErrorHandler func(*gin.Context, error)
+	componentNames    map[reflect.Type]string
+	securitySchemes   openapi3.SecuritySchemes
+	typeSchemas       map[reflect.Type]*openapi3.Schema
+	strictRequired    bool
+	omitZeroExamples  bool
+
+	skipValidationRoutes map[string]bool
+	routers              []*docRouter
+}
+
+func NewSpec(description, version, title string) *Spec {
+	return &Spec{
+		root: newDocRoot(description, version, title),
+		refs: make(map[string]interface{}),
+	}
+}
+
+func newDocRoot(description, version, title string) *openapi3.T {
+	return &openapi3.T{
 		OpenAPI: "3.0.0",
 		Info: &openapi3.Info{
 			Description: description,
@@ -48,15 +101,35 @@ func Init(description, version, title string) {
 	}
 }
 
-var Refs = make(map[string]interface{})
-
-var ginToOpenAPIPathPattern = regexp.MustCompile(`/(:)([^/]+)`)
+func (s *Spec) Root() *openapi3.T {
+	return s.root
+}
 
-func DocDefineRef(key string, val interface{}) {
-	if _, exists := Refs[key]; exists {
+func (s *Spec) DefineRef(key string, val interface{}) {
+	if _, exists := s.refs[key]; exists {
 		panic(fmt.Sprintf("docRef key=%s already exists", key))
 	}
-	Refs[key] = val
+	s.refs[key] = val
+}
+
+// Init resets the document of DefaultSpec, the refs, security schemes and type schemas are kept so that they can be
+// defined in init().
+func Init(description, version, title string) {
+	DefaultSpec.root = newDocRoot(description, version, title)
+	DefaultSpec.componentNames = nil
+	DefaultSpec.attachSecuritySchemes()
+	DefaultSpec.resetRouters()
+	DocRoot = DefaultSpec.root
+}
+
+var ginToOpenAPIPathPattern = regexp.MustCompile(`/([:*])([^/]+)`)
+
+// ExtensionWildcard marks a path parameter of a gin catch-all segment like *filepath, its value may contain slashes and
+// it starts with the slash kept by gin, e.g. / for the bare prefix.
+const ExtensionWildcard = "x-ginx-wildcard"
+
+func DocDefineRef(key string, val interface{}) {
+	DefaultSpec.DefineRef(key, val)
 }
 
 func Header(name string) *docParam {
@@ -71,91 +144,178 @@ func Query(name string) *docParam {
 	return &docParam{name: name, required: true}
 }
 
-func MultiPartFormRequestBody(prototype interface{}) *docRequestBody {
-	schemeRef := extractSchema(prototype)
+func Cookie(name string) *docParam {
+	return &docParam{name: name, required: true}
+}
+
+// RequestBody documents a required request body of mediaType, e.g. RequestBody("application/xml", user). A nil
+// prototype, or bytes of a media type other than JSON, is documented as a binary string, e.g. an uploaded image.
+func RequestBody(mediaType string, prototype interface{}) *docRequestBody {
 	return &docRequestBody{
-		required: true,
-		contents: map[string]*openapi3.SchemaRef{
-			"multipart/form-data": schemeRef,
+		required:  true,
+		mediaType: mediaType,
+		contents: map[string]*docContent{
+			mediaType: newDocContent(mediaType, prototype),
 		},
 	}
 }
 
-func UrlEncodedFormRequestBody(prototype interface{}) *docRequestBody {
-	schemeRef := extractSchema(prototype)
-	return &docRequestBody{
-		required: true,
-		contents: map[string]*openapi3.SchemaRef{
-			"application/x-www-form-urlencoded": schemeRef,
+// ResponseBody documents a response body of mediaType like RequestBody does, e.g.
+// ResponseBody("application/vnd.api+json", user) or ResponseBody("image/png", nil).
+func ResponseBody(mediaType string, prototype interface{}) *docResponse {
+	return &docResponse{
+		mediaType: mediaType,
+		contents: map[string]*docContent{
+			mediaType: newDocContent(mediaType, prototype),
 		},
 	}
 }
 
+func MultiPartFormRequestBody(prototype interface{}) *docRequestBody {
+	return RequestBody("multipart/form-data", prototype)
+}
+
+func UrlEncodedFormRequestBody(prototype interface{}) *docRequestBody {
+	return RequestBody("application/x-www-form-urlencoded", prototype)
+}
+
 func JSONRequestBody(prototype interface{}) *docRequestBody {
-	schemeRef := extractSchema(prototype)
-	if b, err := json.Marshal(prototype); err == nil {
-		var obj map[string]interface{}
-		if err = json.Unmarshal(b, &obj); err == nil {
-			schemeRef.Value.Example = obj
-		}
+	return RequestBody("application/json", prototype)
+}
+
+func JSONResponseBody(prototype interface{}) *docResponse {
+	return ResponseBody("application/json", prototype)
+}
+
+func TextResponseBody(text string) *docResponse {
+	return ResponseBody("text/plain", text)
+}
+
+func CSVResponseBody(text string) *docResponse {
+	return ResponseBody("text/csv", text)
+}
+
+func PDFResponseBody() *docResponse {
+	return ResponseBody("application/pdf", nil)
+}
+
+// docContent defers the schema extraction of a prototype until it is attached to a docPath,
+// where the owning Spec is known.
+type docContent struct {
+	prototype   interface{}
+	jsonExample bool
+	binary      bool
+	examples    map[string]interface{}
+	encoding    map[string]*openapi3.Encoding
+	// zero tells that the prototype is the zero value of its type, which describes the schema but not an example
+	zero bool
+}
+
+func newDocContent(mediaType string, prototype interface{}) *docContent {
+	jsonMediaType := isJSONMediaType(mediaType)
+	_, isBytes := prototype.([]byte)
+	return &docContent{
+		prototype:   prototype,
+		jsonExample: jsonMediaType,
+		binary:      !jsonMediaType && (prototype == nil || isBytes),
 	}
-	return &docRequestBody{
-		required: true,
-		contents: map[string]*openapi3.SchemaRef{
-			"application/json": schemeRef,
-		},
+}
+
+func (d *docContent) schemaRef(s *Spec) *openapi3.SchemaRef {
+	if d.binary {
+		return openapi3.NewSchemaRef("", openapi3.NewStringSchema().WithFormat("binary"))
 	}
+	if d.zero {
+		return s.zeroExampleFreeSchema(d.prototype)
+	}
+	return s.extractSchema(d.prototype)
 }
 
-func JSONResponseBody(prototype interface{}) *docResponse {
-	schemeRef := extractSchema(prototype)
-	if b, err := json.Marshal(prototype); err == nil {
-		var inInterface map[string]interface{}
-		if err = json.Unmarshal(b, &inInterface); err == nil {
-			schemeRef.Value.Example = inInterface
+func (d *docContent) mediaType(s *Spec) *openapi3.MediaType {
+	mt := &openapi3.MediaType{
+		Schema:   d.schemaRef(s),
+		Encoding: d.encoding,
+	}
+	if d.examples != nil {
+		// example and examples are mutually exclusive
+		mt.Examples = make(openapi3.Examples, len(d.examples))
+		for name, example := range d.examples {
+			if d.jsonExample {
+				example = jsonValue(example)
+			}
+			mt.Examples[name] = &openapi3.ExampleRef{Value: openapi3.NewExample(example)}
 		}
+		return mt
 	}
-	return &docResponse{
-		contents: map[string]*openapi3.SchemaRef{
-			"application/json": schemeRef,
-		},
+	// the example belongs to the media type since the schema could be a shared component
+	if d.jsonExample {
+		if b, err := json.Marshal(d.prototype); err == nil {
+			var obj map[string]interface{}
+			if err = json.Unmarshal(b, &obj); err == nil {
+				mt.Example = obj
+			}
+		}
 	}
+	return mt
 }
 
-func TextResponseBody(text string) *docResponse {
-	return &docResponse{
-		contents: map[string]*openapi3.SchemaRef{
-			"text/plain": extractSchema(text),
-		},
+// registerBodyDecoder lets the validators decode a vendor JSON or a binary media type, e.g. application/vnd.api+json
+// or image/png, other media types without a decoder must be registered by openapi3filter.RegisterBodyDecoder.
+func (d *docContent) registerBodyDecoder(mediaType string) {
+	if strings.Contains(mediaType, "*") || openapi3filter.RegisteredBodyDecoder(mediaType) != nil {
+		return
+	}
+	if isJSONMediaType(mediaType) {
+		openapi3filter.RegisterBodyDecoder(mediaType, openapi3filter.JSONBodyDecoder)
+	} else if d.binary {
+		openapi3filter.RegisterBodyDecoder(mediaType, openapi3filter.FileBodyDecoder)
 	}
 }
 
-func CSVResponseBody(text string) *docResponse {
-	return &docResponse{
-		contents: map[string]*openapi3.SchemaRef{
-			"text/csv": extractSchema(text),
-		},
+// setExamples sets the named examples of the content of mediaType, which is the one the body is created with.
+func setExamples(contents map[string]*docContent, mediaType string, examples map[string]interface{}) {
+	content, exists := contents[mediaType]
+	if !exists {
+		panic(fmt.Sprintf("media type=%s of the body doesn't exist", mediaType))
 	}
+	content.examples = examples
 }
 
-func PDFResponseBody() *docResponse {
-	return &docResponse{
-		contents: map[string]*openapi3.SchemaRef{
-			"application/pdf": extractSchema(""),
-		},
+// setEncoding sets the content type of the property of a multipart or form body of mediaType.
+func setEncoding(contents map[string]*docContent, mediaType, property, contentType string) {
+	content, exists := contents[mediaType]
+	if !exists {
+		panic(fmt.Sprintf("media type=%s of the body doesn't exist", mediaType))
+	}
+	if content.encoding == nil {
+		content.encoding = make(map[string]*openapi3.Encoding)
 	}
+	content.encoding[property] = &openapi3.Encoding{ContentType: contentType}
 }
 
 func newDocPath(route *route) *docPath {
+	s := route.spec
 	var p *openapi3.PathItem
 	ph := ginToOpenAPIPathPattern.ReplaceAllString(route.httpPath, `/{$2}`)
-	if pathItemObj := DocRoot.Paths.Value(ph); pathItemObj != nil {
+	if pathItemObj := s.root.Paths.Value(ph); pathItemObj != nil {
 		p = pathItemObj
 	} else {
 		p = &openapi3.PathItem{}
 	}
 	op := &openapi3.Operation{}
 	op.Responses = openapi3.NewResponses()
+	// every template variable is documented as a required string until it is declared by Path
+	for _, m := range ginToOpenAPIPathPattern.FindAllStringSubmatch(route.httpPath, -1) {
+		param := openapi3.NewPathParameter(m[2]).WithSchema(openapi3.NewStringSchema())
+		if m[1] == "*" {
+			param.Schema.Value.Pattern = "^/"
+			param.Extensions = map[string]interface{}{ExtensionWildcard: true}
+		}
+		op.AddParameter(param)
+	}
+	if route.group != nil {
+		op.Security = route.group.defaultSecurity()
+	}
 	switch route.httpMethod {
 	case http.MethodGet:
 		p.Get = op
@@ -174,13 +334,17 @@ func newDocPath(route *route) *docPath {
 	case http.MethodTrace:
 		p.Trace = op
 	}
-	DocRoot.Paths.Set(ph, p)
-	return &docPath{p, op}
+	s.root.Paths.Set(ph, p)
+	return &docPath{spec: s, httpPath: route.httpPath, pathItem: p, operation: op}
 }
 
 type docPath struct {
-	pathItem  *openapi3.PathItem
-	operation *openapi3.Operation
+	spec        *Spec
+	httpPath    string
+	pathItem    *openapi3.PathItem
+	operation   *openapi3.Operation
+	hasResponse bool
+	hasSecurity bool
 }
 
 func (d *docPath) Tag(tag string) *docPath {
@@ -193,28 +357,56 @@ func (d *docPath) Summary(summary string) *docPath {
 	return d
 }
 
+func (d *docPath) OperationID(id string) *docPath {
+	d.operation.OperationID = id
+	return d
+}
+
 func (d *docPath) Description(description string) *docPath {
 	d.operation.Description = description
 	return d
 }
 
 func (d *docPath) Header(header *docParam) *docPath {
-	d.operation.Parameters = append(d.operation.Parameters, header.ToOpenAPIParam(ParamHeader))
+	d.operation.Parameters = append(d.operation.Parameters, header.ToOpenAPIParam(d.spec, ParamHeader))
 	return d
 }
 
 func (d *docPath) Path(path *docParam) *docPath {
-	d.operation.Parameters = append(d.operation.Parameters, path.ToOpenAPIParam(ParamPath))
+	d.addParam(path.ToOpenAPIParam(d.spec, ParamPath))
 	return d
 }
 
+func (d *docPath) addParam(param *openapi3.ParameterRef) {
+	if param.Value.In != ParamPath {
+		d.operation.Parameters = append(d.operation.Parameters, param)
+		return
+	}
+	for i, p := range d.operation.Parameters {
+		if p.Value != nil && p.Value.In == ParamPath && p.Value.Name == param.Value.Name {
+			// replaces the parameter inferred from the route, which keeps its extensions
+			if param.Value.Extensions == nil {
+				param.Value.Extensions = p.Value.Extensions
+			}
+			d.operation.Parameters[i] = param
+			return
+		}
+	}
+	panic(fmt.Sprintf("path parameter=%s is not in the route path=%s", param.Value.Name, d.httpPath))
+}
+
 func (d *docPath) Query(query *docParam) *docPath {
-	d.operation.Parameters = append(d.operation.Parameters, query.ToOpenAPIParam(ParamQuery))
+	d.operation.Parameters = append(d.operation.Parameters, query.ToOpenAPIParam(d.spec, ParamQuery))
+	return d
+}
+
+func (d *docPath) Cookie(cookie *docParam) *docPath {
+	d.operation.Parameters = append(d.operation.Parameters, cookie.ToOpenAPIParam(d.spec, ParamCookie))
 	return d
 }
 
 func (d *docPath) RequestBody(body *docRequestBody) *docPath {
-	d.operation.RequestBody = body.ToOpenAPIRequestBody()
+	d.operation.RequestBody = body.ToOpenAPIRequestBody(d.spec)
 	return d
 }
 
@@ -222,17 +414,29 @@ func (d *docPath) Response(httpCode string, resp *docResponse, desc string) *doc
 	if d.operation.Responses == nil {
 		d.operation.Responses = openapi3.NewResponses()
 	}
+	if !d.hasResponse {
+		// openapi3.NewResponses comes with a placeholder default response which would accept any status code
+		d.operation.Responses.Delete("default")
+		d.hasResponse = true
+	}
 
 	resp.description = &desc
-	d.operation.Responses.Set(httpCode, resp.ToOpenAPIResponse())
+	d.operation.Responses.Set(httpCode, resp.ToOpenAPIResponse(d.spec))
 	return d
 }
 
 type docParam struct {
-	name        string
-	description string
-	required    bool
-	schemaRef   *openapi3.SchemaRef
+	name            string
+	description     string
+	required        bool
+	schema          *docContent
+	enum            []interface{}
+	style           string
+	explode         *bool
+	allowEmptyValue bool
+	deprecated      bool
+	example         interface{}
+	contents        map[string]*docContent
 }
 
 func (d *docParam) Description(desc string) *docParam {
@@ -246,28 +450,91 @@ func (d *docParam) Required(required bool) *docParam {
 }
 
 func (d *docParam) Schema(example interface{}) *docParam {
-	d.schemaRef = extractSchema(example)
+	d.schema = &docContent{prototype: example}
 	return d
 }
 
 func (d *docParam) Enum(values ...interface{}) *docParam {
-	if scheme := d.schemaRef.Value; scheme != nil {
-		d.schemaRef.Value.Enum = values
+	if d.schema != nil {
+		d.enum = values
 		return d
 	} else {
 		panic("scheme is nil, must call Scheme(example interface{}) first")
 	}
 }
 
-func (d *docParam) ToOpenAPIParam(in string) *openapi3.ParameterRef {
+// Style is the serialization of the parameter, e.g. openapi3.SerializationPipeDelimited for ?ids=1|2, the default is
+// form for query and cookie parameters and simple for path and header parameters.
+func (d *docParam) Style(style string) *docParam {
+	d.style = style
+	return d
+}
+
+// Explode tells whether an array or object is serialized as separate parameters, e.g. ?ids=1&ids=2, rather than
+// ?ids=1,2. The default is true for the form style and false for the others.
+func (d *docParam) Explode(explode bool) *docParam {
+	d.explode = &explode
+	return d
+}
+
+func (d *docParam) AllowEmptyValue(allow bool) *docParam {
+	d.allowEmptyValue = allow
+	return d
+}
+
+func (d *docParam) Deprecated(deprecated bool) *docParam {
+	d.deprecated = deprecated
+	return d
+}
+
+// Example is the value of the parameter after it is deserialized, e.g. []int{1, 2} for ?ids=1,2.
+func (d *docParam) Example(example interface{}) *docParam {
+	d.example = example
+	return d
+}
+
+// Content documents a parameter which is serialized as a media type, e.g. a JSON encoded query parameter, instead
+// of by its schema and style.
+func (d *docParam) Content(mediaType string, prototype interface{}) *docParam {
+	if d.contents == nil {
+		d.contents = make(map[string]*docContent)
+	}
+	d.contents[mediaType] = &docContent{prototype: prototype}
+	return d
+}
+
+func (d *docParam) ToOpenAPIParam(s *Spec, in string) *openapi3.ParameterRef {
+	if d.schema != nil && d.contents != nil {
+		panic(fmt.Sprintf("parameter=%s must have either a schema or a content", d.name))
+	}
+	var schemaRef *openapi3.SchemaRef
+	if d.schema != nil {
+		schemaRef = d.schema.schemaRef(s)
+		if schemaRef != nil && d.enum != nil {
+			setEnum(schemaRef.Value, d.enum)
+		}
+	}
+	var content openapi3.Content
+	for mediaType, c := range d.contents {
+		if content == nil {
+			content = openapi3.NewContent()
+		}
+		content[mediaType] = c.mediaType(s)
+	}
 	return &openapi3.ParameterRef{
 		Ref: "",
 		Value: &openapi3.Parameter{
-			Name:        d.name,
-			In:          in,
-			Description: d.description,
-			Required:    d.required,
-			Schema:      d.schemaRef,
+			Name:            d.name,
+			In:              in,
+			Description:     d.description,
+			Style:           d.style,
+			Explode:         d.explode,
+			AllowEmptyValue: d.allowEmptyValue,
+			Deprecated:      d.deprecated,
+			Required:        d.required,
+			Schema:          schemaRef,
+			Example:         jsonValue(d.example),
+			Content:         content,
 		},
 	}
 }
@@ -275,7 +542,20 @@ func (d *docParam) ToOpenAPIParam(in string) *openapi3.ParameterRef {
 type docRequestBody struct {
 	description *string
 	required    bool
-	contents    map[string]*openapi3.SchemaRef
+	mediaType   string
+	contents    map[string]*docContent
+}
+
+// Examples documents the named examples of the body, e.g. Examples(map[string]interface{}{"admin": admin}).
+func (d *docRequestBody) Examples(examples map[string]interface{}) *docRequestBody {
+	setExamples(d.contents, d.mediaType, examples)
+	return d
+}
+
+// Encoding documents the content type of a part of a multipart body, e.g. Encoding("avatar", "image/png").
+func (d *docRequestBody) Encoding(property, contentType string) *docRequestBody {
+	setEncoding(d.contents, d.mediaType, property, contentType)
+	return d
 }
 
 func (d *docRequestBody) Required(required bool) *docRequestBody {
@@ -288,16 +568,15 @@ func (d *docRequestBody) Description(desc string) *docRequestBody {
 	return d
 }
 
-func (d *docRequestBody) ToOpenAPIRequestBody() *openapi3.RequestBodyRef {
+func (d *docRequestBody) ToOpenAPIRequestBody(s *Spec) *openapi3.RequestBodyRef {
 	b := openapi3.NewRequestBody().WithRequired(d.required)
 	if d.description != nil {
 		b.WithDescription(*d.description)
 	}
 	b.Content = openapi3.NewContent()
-	for mediaType, schemaRef := range d.contents {
-		b.Content[mediaType] = &openapi3.MediaType{
-			Schema: schemaRef,
-		}
+	for mediaType, content := range d.contents {
+		b.Content[mediaType] = content.mediaType(s)
+		content.registerBodyDecoder(mediaType)
 	}
 	return &openapi3.RequestBodyRef{
 		Ref:   "",
@@ -307,17 +586,75 @@ func (d *docRequestBody) ToOpenAPIRequestBody() *openapi3.RequestBodyRef {
 
 type docResponse struct {
 	description *string
-	contents    map[string]*openapi3.SchemaRef
+	mediaType   string
+	contents    map[string]*docContent
+	headers     []*docParam
+	links       map[string]*docLink
+}
+
+// Examples documents the named examples of the body, the other media types added by Also keep their own example.
+func (d *docResponse) Examples(examples map[string]interface{}) *docResponse {
+	setExamples(d.contents, d.mediaType, examples)
+	return d
+}
+
+// Encoding documents the content type of a part of a multipart body.
+func (d *docResponse) Encoding(property, contentType string) *docResponse {
+	setEncoding(d.contents, d.mediaType, property, contentType)
+	return d
 }
 
-func (d *docResponse) ToOpenAPIResponse() *openapi3.ResponseRef {
+// Header documents a header of the response, e.g. Header(Header("Location").Schema("/users/1")), it is required
+// unless Required(false) is called.
+func (d *docResponse) Header(header *docParam) *docResponse {
+	d.headers = append(d.headers, header)
+	return d
+}
+
+// Also documents another media type of the response, which is chosen by content negotiation, e.g.
+// JSONResponseBody(users).Also("text/csv", "id,name").
+func (d *docResponse) Also(mediaType string, prototype interface{}) *docResponse {
+	if _, exists := d.contents[mediaType]; exists {
+		panic(fmt.Sprintf("media type=%s of the response already exists", mediaType))
+	}
+	if d.contents == nil {
+		d.contents = make(map[string]*docContent)
+	}
+	d.contents[mediaType] = newDocContent(mediaType, prototype)
+	return d
+}
+
+// Link documents an operation which can follow the response.
+func (d *docResponse) Link(name string, link *docLink) *docResponse {
+	if d.links == nil {
+		d.links = make(map[string]*docLink)
+	}
+	d.links[name] = link
+	return d
+}
+
+func (d *docResponse) ToOpenAPIResponse(s *Spec) *openapi3.ResponseRef {
 	b := openapi3.NewResponse()
 	b.Content = openapi3.NewContent()
 	b.Description = d.description
-	for mediaType, schemaRef := range d.contents {
-		b.Content[mediaType] = &openapi3.MediaType{
-			Schema: schemaRef,
+	for mediaType, content := range d.contents {
+		b.Content[mediaType] = content.mediaType(s)
+		content.registerBodyDecoder(mediaType)
+	}
+	for _, header := range d.headers {
+		if b.Headers == nil {
+			b.Headers = make(openapi3.Headers)
 		}
+		// the name and location of a response header are implied by the key
+		param := header.ToOpenAPIParam(s, ParamHeader).Value
+		param.Name, param.In = "", ""
+		b.Headers[header.name] = &openapi3.HeaderRef{Value: &openapi3.Header{Parameter: *param}}
+	}
+	for name, link := range d.links {
+		if b.Links == nil {
+			b.Links = make(openapi3.Links)
+		}
+		b.Links[name] = &openapi3.LinkRef{Value: link.link}
 	}
 	return &openapi3.ResponseRef{
 		Ref:   "",
@@ -325,15 +662,121 @@ func (d *docResponse) ToOpenAPIResponse() *openapi3.ResponseRef {
 	}
 }
 
-func extractSchema(prototype interface{}) *openapi3.SchemaRef {
+type docLink struct {
+	link *openapi3.Link
+}
+
+// Link refers to the operation operationID, its parameters and request body are given by runtime expressions like
+// $response.body#/id.
+func Link(operationID string) *docLink {
+	return &docLink{link: &openapi3.Link{OperationID: operationID}}
+}
+
+func (d *docLink) Description(desc string) *docLink {
+	d.link.Description = desc
+	return d
+}
+
+func (d *docLink) Parameter(name string, expression interface{}) *docLink {
+	if d.link.Parameters == nil {
+		d.link.Parameters = make(map[string]interface{})
+	}
+	d.link.Parameters[name] = expression
+	return d
+}
+
+func (d *docLink) RequestBody(expression interface{}) *docLink {
+	d.link.RequestBody = expression
+	return d
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// Enum is implemented by a named type which has a fixed set of values, they are documented as the enum of its schema.
+type Enum interface {
+	EnumValues() []interface{}
+}
+
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+func (s *Spec) extractSchema(prototype interface{}) *openapi3.SchemaRef {
+	schemaRef := s.extractTypeSchema(prototype)
+	if schemaRef != nil && schemaRef.Ref == "" {
+		if values := enumValues(prototype); values != nil {
+			setEnum(schemaRef.Value, values)
+		}
+	}
+	return schemaRef
+}
+
+// enumValues returns the values of the Enum implemented by the type of prototype, the receiver could be a nil pointer
+// so the values are read from the zero value of the type.
+func enumValues(prototype interface{}) []interface{} {
+	t := derefType(reflect.TypeOf(prototype))
+	if t.Implements(enumType) {
+		return reflect.Zero(t).Interface().(Enum).EnumValues()
+	}
+	if reflect.PointerTo(t).Implements(enumType) {
+		return reflect.New(t).Interface().(Enum).EnumValues()
+	}
+	return nil
+}
+
+// setEnum stores the values as their JSON decoded form, e.g. a number is a float64, so that they are equal to the
+// decoded request values on validation. The example is replaced by the first value when it is not one of them.
+func setEnum(sch *openapi3.Schema, values []interface{}) {
+	sch.Enum = make([]interface{}, len(values))
+	for i, v := range values {
+		sch.Enum[i] = jsonValue(v)
+	}
+	if sch.Example != nil && len(sch.Enum) > 0 && sch.VisitJSON(sch.Example) != nil {
+		sch.Example = sch.Enum[0]
+	}
+}
+
+func (s *Spec) extractTypeSchema(prototype interface{}) *openapi3.SchemaRef {
 	if prototype == nil {
 		return nil
 	}
+	if composition, ok := prototype.(*docComposition); ok {
+		return composition.schemaRef(s)
+	}
+	if sch := s.customSchema(prototype); sch != nil {
+		return openapi3.NewSchemaRef("", sch)
+	}
 	if vh := extractFileHeader(prototype); vh != nil {
 		sch := openapi3.NewStringSchema()
 		sch.Format = "binary"
 		return openapi3.NewSchemaRef("", sch)
 	}
+	if vh := extractExactType(prototype, timeType); vh != nil {
+		sch := openapi3.NewDateTimeSchema()
+		if vh.Value.IsValid() && !vh.Value.Interface().(time.Time).IsZero() {
+			sch.Example = vh.Value.Interface().(time.Time).Format(time.RFC3339Nano)
+		}
+		return openapi3.NewSchemaRef("", sch)
+	}
+	if vh := extractExactType(prototype, durationType); vh != nil {
+		// encoding/json renders time.Duration as the integer of nanoseconds
+		sch := openapi3.NewInt64Schema()
+		sch.Description = "duration in nanoseconds"
+		if vh.Value.IsValid() {
+			sch.Example = vh.Value.Int()
+		}
+		return openapi3.NewSchemaRef("", sch)
+	}
+	if vh := extractExactType(prototype, rawMessageType); vh != nil {
+		return openapi3.NewSchemaRef("", &openapi3.Schema{})
+	}
+	if vh := extractBytes(prototype); vh != nil {
+		sch := openapi3.NewBytesSchema()
+		if vh.Value.IsValid() && vh.Value.Len() > 0 {
+			sch.Example = base64.StdEncoding.EncodeToString(vh.Value.Bytes())
+		}
+		return openapi3.NewSchemaRef("", sch)
+	}
 	if vh := extractString(prototype); vh != nil {
 		sch := openapi3.NewStringSchema()
 		if vh.Value.Kind() != reflect.Invalid {
@@ -344,14 +787,22 @@ func extractSchema(prototype interface{}) *openapi3.SchemaRef {
 	if vh := extractInteger(prototype); vh != nil {
 		sch := openapi3.NewIntegerSchema()
 		if vh.Value.Kind() != reflect.Invalid {
-			sch.Example = vh.Value.Interface()
+			sch.Example = vh.Value.Int()
+		}
+		return openapi3.NewSchemaRef("", sch)
+	}
+	if vh := extractUnsignedInteger(prototype); vh != nil {
+		sch := openapi3.NewIntegerSchema().WithMin(0)
+		if vh.Value.Kind() != reflect.Invalid {
+			// openapi3 validates the examples of int, int32, int64, float64 and json.Number only
+			sch.Example = json.Number(strconv.FormatUint(vh.Value.Uint(), 10))
 		}
 		return openapi3.NewSchemaRef("", sch)
 	}
 	if vh := extractNumber(prototype); vh != nil {
 		sch := openapi3.NewFloat64Schema()
 		if vh.Value.Kind() != reflect.Invalid {
-			sch.Example = vh.Value.Interface()
+			sch.Example = vh.Value.Float()
 		}
 		return openapi3.NewSchemaRef("", sch)
 	}
@@ -364,8 +815,9 @@ func extractSchema(prototype interface{}) *openapi3.SchemaRef {
 	}
 	if vh := extractMap(prototype); vh != nil {
 		sch := openapi3.NewObjectSchema()
-		if vh.Value.Kind() != reflect.Invalid {
-			sch.Example = vh.Value.Interface()
+		sch.AdditionalProperties = openapi3.AdditionalProperties{Schema: s.zeroSchema(vh.Type.Elem())}
+		if vh.Value.Kind() != reflect.Invalid && vh.Value.Len() > 0 {
+			sch.Example = jsonValue(vh.Value.Interface())
 		}
 		return openapi3.NewSchemaRef("", sch)
 	}
@@ -373,130 +825,436 @@ func extractSchema(prototype interface{}) *openapi3.SchemaRef {
 		sch := openapi3.NewArraySchema()
 		t := openapi3.Types([]string{"array"})
 		sch.Type = &t
-		if vh.Value.Len() == 0 {
-			panic(fmt.Sprintf("prototype=%+v should has one or more elements in an array", prototype))
+		if vh.Value.Kind() == reflect.Invalid || vh.Value.Len() == 0 || vh.Type.Elem().Kind() == reflect.Interface {
+			// the items of an interface slice could be of any type, the first one doesn't describe the others
+			sch.Items = s.zeroSchema(vh.Type.Elem())
+		} else {
+			sch.Items = s.valueSchema(vh.Value.Index(0))
+		}
+		if vh.Type.Kind() == reflect.Array {
+			sch.MinItems = uint64(vh.Type.Len())
+			sch.MaxItems = openapi3.Uint64Ptr(uint64(vh.Type.Len()))
 		}
-		sch.Items = extractSchema(vh.Value.Index(0).Interface())
 		return openapi3.NewSchemaRef("", sch)
 	}
 	if vh := extractStruct(prototype); vh != nil {
-		objSch := openapi3.NewObjectSchema()
-		objSch.Properties = make(map[string]*openapi3.SchemaRef)
-		num := vh.Type.NumField()
-		for i := 0; i < num; i++ {
-			field := vh.Type.Field(i)
-			val := vh.Value.Field(i)
-			if val.Kind() == reflect.Struct && field.Anonymous {
-				sr := extractSchema(val.Interface())
-				objSch.Required = append(objSch.Required, sr.Value.Required...)
-				for k, v := range sr.Value.Properties {
-					objSch.Properties[k] = v
-				}
-				continue
-			}
+		if !vh.Value.IsValid() {
+			// a nil pointer to struct is documented by the zero value of the struct
+			vh.Value = reflect.Zero(vh.Type)
+			prototype = vh.Value.Interface()
+		}
+		if vh.Type.Name() == "" {
+			return openapi3.NewSchemaRef("", s.structSchema(prototype, vh))
+		}
+		return s.componentSchemaRef(vh.Type, func() *openapi3.Schema {
+			return s.structSchema(prototype, vh)
+		})
+	}
+
+	switch derefType(reflect.TypeOf(prototype)).Kind() {
+	case reflect.Interface:
+		return openapi3.NewSchemaRef("", &openapi3.Schema{})
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		// encoding/json can't encode them, they are left out of the schema
+		return nil
+	}
+
+	panic(fmt.Sprintf("prototype=%+v not supported", prototype))
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// zeroExampleFreeSchema extracts the schema of prototype without the examples of its zero values, e.g. the empty
+// string of a field with a pattern.
+func (s *Spec) zeroExampleFreeSchema(prototype interface{}) *openapi3.SchemaRef {
+	s.omitZeroExamples = true
+	defer func() { s.omitZeroExamples = false }()
+	schemaRef := s.extractSchema(prototype)
+	if schemaRef != nil && schemaRef.Ref == "" && reflect.ValueOf(prototype).IsZero() {
+		schemaRef.Value.Example = nil
+	}
+	return schemaRef
+}
 
-			fTag := field.Tag.Get("json")
-			fieldNameArr := strings.Split(fTag, ",")
-			if len(fieldNameArr[0]) == 0 || fieldNameArr[0] == "-" {
-				fTag = field.Tag.Get("form")
-				fieldNameArr = strings.Split(fTag, ",")
-				if len(fieldNameArr[0]) == 0 || fieldNameArr[0] == "-" {
-					continue
+// zeroSchema is the schema of the type t, an interface type is schemaless since its zero value is nil.
+func (s *Spec) zeroSchema(t reflect.Type) *openapi3.SchemaRef {
+	if t.Kind() == reflect.Interface {
+		return openapi3.NewSchemaRef("", &openapi3.Schema{})
+	}
+	return s.extractSchema(reflect.Zero(t).Interface())
+}
+
+// valueSchema is the schema of the value v, which falls back to the type of v when v is an interface, whose value
+// doesn't describe the other values it could hold, or it is read from an unexported embedded struct.
+func (s *Spec) valueSchema(v reflect.Value) *openapi3.SchemaRef {
+	if v.Kind() == reflect.Interface || !v.CanInterface() {
+		return s.zeroSchema(v.Type())
+	}
+	return s.extractSchema(v.Interface())
+}
+
+// jsonValue converts v to the form decoded from its JSON, e.g. a struct becomes a map and a number becomes a float64,
+// v is returned as it is when it can't be encoded.
+func jsonValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var decoded interface{}
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		return v
+	}
+	return decoded
+}
+
+func (s *Spec) structSchema(prototype interface{}, vh *valHolder) *openapi3.Schema {
+	objSch := openapi3.NewObjectSchema()
+	objSch.Properties = make(map[string]*openapi3.SchemaRef)
+	fields := s.structFields(prototype, vh, 0)
+	for i, f := range fields {
+		if !dominantField(i, fields) {
+			continue
+		}
+		if f.required && !slices.Contains(objSch.Required, f.name) {
+			objSch.Required = append(objSch.Required, f.name)
+		}
+		objSch.Properties[f.name] = f.schemaRef
+	}
+	return objSch
+}
+
+// schemaField is a property of a struct schema, depth counts the embedded structs it is promoted from.
+type schemaField struct {
+	name      string
+	schemaRef *openapi3.SchemaRef
+	required  bool
+	depth     int
+	tagged    bool
+}
+
+// dominantField tells whether fields[i] is the field encoding/json encodes under its name: the shallowest one, or the
+// only tagged one among the shallowest, a name with several candidates left is not encoded at all.
+func dominantField(i int, fields []schemaField) bool {
+	f := fields[i]
+	for j, other := range fields {
+		if j == i || other.name != f.name {
+			continue
+		}
+		if other.depth < f.depth || other.depth == f.depth && (other.tagged || !f.tagged) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Spec) structFields(prototype interface{}, vh *valHolder, depth int) []schemaField {
+	var fields []schemaField
+	num := vh.Type.NumField()
+	for i := 0; i < num; i++ {
+		field := vh.Type.Field(i)
+		val := vh.Value.Field(i)
+		fn, opts, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+		if field.Anonymous && derefType(field.Type).Kind() == reflect.Struct && fn == "" {
+			embedded := &valHolder{Type: derefType(field.Type), Value: val}
+			for embedded.Value.Kind() == reflect.Ptr {
+				if embedded.Value.IsNil() {
+					embedded.Value = reflect.Zero(embedded.Type)
+				} else {
+					embedded.Value = embedded.Value.Elem()
 				}
 			}
-			fn := fieldNameArr[0]
+			fields = append(fields, s.structFields(reflect.Zero(embedded.Type).Interface(), embedded, depth+1)...)
+			continue
+		}
 
-			docStr := field.Tag.Get(RootTag)
-			if len(docStr) == 0 {
-				continue
-			}
+		if field.PkgPath != "" {
+			// unexported fields are not encoded, an embedded unexported struct is flattened above
+			continue
+		}
+		tagged := fn != ""
+		if !tagged {
+			fn = field.Name
+		}
 
-			schemeRef := extractSchema(val.Interface())
-			if schemeRef == nil {
-				continue
+		schemeRef := s.valueSchema(val)
+		if schemeRef == nil {
+			continue
+		}
+		if opts["string"] && isStringOptionKind(derefType(field.Type).Kind()) {
+			schemeRef = stringOptionSchema(val)
+		}
+		if s.omitZeroExamples && schemeRef.Ref == "" && val.IsZero() {
+			schemeRef.Value.Example = nil
+		}
+		schemeRef, required := s.fieldSchema(schemeRef, prototype, field)
+		if s.strictRequired && field.Type.Kind() != reflect.Ptr && !opts["omitempty"] {
+			required = true
+		}
+		fields = append(fields, schemaField{name: fn, schemaRef: schemeRef, required: required, depth: depth, tagged: tagged})
+	}
+	return fields
+}
+
+// fieldSchema applies the doc tags of field to its schema, required tells whether the field has the required doc tag.
+func (s *Spec) fieldSchema(schemeRef *openapi3.SchemaRef, prototype interface{}, field reflect.StructField) (_ *openapi3.SchemaRef, required bool) {
+	kvs := parseDocTag(field.Tag.Get(RootTag))
+	if schemeRef.Ref != "" && hasSchemaModifier(kvs) {
+		// siblings of $ref are ignored, so the field level keywords go to an allOf wrapper
+		schemeRef = openapi3.NewSchemaRef("", &openapi3.Schema{AllOf: openapi3.SchemaRefs{schemeRef}})
+	}
+	for _, schemaFieldKV := range kvs {
+		switch schemaFieldKV[0] {
+		case DocTagFieldMaxItems:
+			if n, err := strconv.ParseInt(schemaFieldKV[1], 10, 64); err != nil {
+				panic(fmt.Sprintf("field %s maxItems %s is not a number", schemaFieldKV[0], schemaFieldKV[1]))
+			} else {
+				un := uint64(n)
+				schemeRef.Value.MaxItems = &un
 			}
-			kvs := parseDocTag(docStr)
-			for _, schemaFieldKV := range kvs {
-				switch schemaFieldKV[0] {
-				case DocTagFieldMaxItems:
-					if n, err := strconv.ParseInt(schemaFieldKV[1], 10, 64); err != nil {
-						panic(fmt.Sprintf("field %s maxItems %s is not a number", schemaFieldKV[0], schemaFieldKV[1]))
-					} else {
-						un := uint64(n)
-						schemeRef.Value.MaxItems = &un
-					}
-				case DocTagFieldMinItems:
-					if n, err := strconv.ParseInt(schemaFieldKV[1], 10, 64); err != nil {
-						panic(fmt.Sprintf("field %s minItems %s is not a number", schemaFieldKV[0], schemaFieldKV[1]))
-					} else {
-						schemeRef.Value.MinItems = uint64(n)
-					}
-				case DocTagFieldNullable:
-					schemeRef.Value.Nullable = true
-				case DocTagFieldRequired:
-					objSch.Required = append(objSch.Required, fn)
-				case DocTagFieldFormat:
-					schemeRef.Value.Format = schemaFieldKV[1]
-				case DocTagFieldPattern:
-					if val, exists := Refs[schemaFieldKV[1]]; exists {
-						schemeRef.Value.Pattern = val.(string)
-					} else {
-						schemeRef.Value.Pattern = schemaFieldKV[1]
-					}
-				case DocTagFieldDescription:
-					if val, exists := Refs[schemaFieldKV[1]]; exists {
-						schemeRef.Value.Description = val.(string)
-					} else {
-						schemeRef.Value.Description = schemaFieldKV[1]
-					}
-				case DocTagFieldEnum:
-					if val, exists := Refs[schemaFieldKV[1]]; exists {
-						schemeRef.Value.Enum = val.([]interface{})
-					} else {
-						sp := strings.Split(schemaFieldKV[1], ";")
-						var enums []interface{}
-						for _, item := range sp {
-							enums = append(enums, item)
-						}
-						schemeRef.Value.Enum = enums
-					}
-				case DocTagFieldStringMaxLength:
-					lessThanOrEqualTo, err := strconv.Atoi(schemaFieldKV[1])
+		case DocTagFieldMinItems:
+			if n, err := strconv.ParseInt(schemaFieldKV[1], 10, 64); err != nil {
+				panic(fmt.Sprintf("field %s minItems %s is not a number", schemaFieldKV[0], schemaFieldKV[1]))
+			} else {
+				schemeRef.Value.MinItems = uint64(n)
+			}
+		case DocTagFieldNullable:
+			schemeRef.Value.Nullable = true
+		case DocTagFieldRequired:
+			required = true
+		case DocTagFieldFormat:
+			schemeRef.Value.Format = schemaFieldKV[1]
+		case DocTagFieldPattern:
+			if val, exists := s.refs[schemaFieldKV[1]]; exists {
+				schemeRef.Value.Pattern = val.(string)
+			} else {
+				schemeRef.Value.Pattern = schemaFieldKV[1]
+			}
+		case DocTagFieldDescription:
+			if val, exists := s.refs[schemaFieldKV[1]]; exists {
+				schemeRef.Value.Description = val.(string)
+			} else {
+				schemeRef.Value.Description = schemaFieldKV[1]
+			}
+		case DocTagFieldEnum:
+			enumSchema, enumType := schemeRef.Value, derefType(field.Type)
+			if (enumType.Kind() == reflect.Slice || enumType.Kind() == reflect.Array) && enumSchema.Items != nil && enumSchema.Items.Ref == "" {
+				// the enum of a slice field constrains its items
+				enumSchema, enumType = enumSchema.Items.Value, derefType(enumType.Elem())
+			}
+			if val, exists := s.refs[schemaFieldKV[1]]; exists {
+				setEnum(enumSchema, val.([]interface{}))
+			} else {
+				sp := strings.Split(schemaFieldKV[1], ";")
+				var enums []interface{}
+				for _, item := range sp {
+					v, err := docTagValue(enumType, item)
 					if err != nil {
 						panic(fmt.Sprintf("prototype=%+v field=%s tag=%s value invalid, err=%v", prototype, field.Name, schemaFieldKV[0], err.Error()))
 					}
-					max := uint64(lessThanOrEqualTo)
-					schemeRef.Value.MaxLength = &max
+					enums = append(enums, v)
+				}
+				setEnum(enumSchema, enums)
+			}
+		case DocTagFieldStringMaxLength:
+			lessThanOrEqualTo, err := strconv.Atoi(schemaFieldKV[1])
+			if err != nil {
+				panic(fmt.Sprintf("prototype=%+v field=%s tag=%s value invalid, err=%v", prototype, field.Name, schemaFieldKV[0], err.Error()))
+			}
+			max := uint64(lessThanOrEqualTo)
+			schemeRef.Value.MaxLength = &max
 
-				case DocTagFieldMaximum:
-					lessThanOrEqualTo, err := strconv.ParseFloat(schemaFieldKV[1], 64)
-					if err != nil {
-						panic(fmt.Sprintf("prototype=%+v field=%s tag=%s value invalid, err=%v", prototype, field.Name, schemaFieldKV[0], err.Error()))
-					}
-					schemeRef.Value.Max = &lessThanOrEqualTo
+		case DocTagFieldMaximum:
+			lessThanOrEqualTo, err := strconv.ParseFloat(schemaFieldKV[1], 64)
+			if err != nil {
+				panic(fmt.Sprintf("prototype=%+v field=%s tag=%s value invalid, err=%v", prototype, field.Name, schemaFieldKV[0], err.Error()))
+			}
+			schemeRef.Value.Max = &lessThanOrEqualTo
 
-				case DocTagFieldStringMinLength:
-					greaterThanOrEqual, err := strconv.Atoi(schemaFieldKV[1])
-					if err != nil {
-						panic(fmt.Sprintf("prototype=%+v field=%s tag=%s value invalid, err=%v", prototype, field.Name, schemaFieldKV[0], err.Error()))
-					}
-					schemeRef.Value.MinLength = uint64(greaterThanOrEqual)
+		case DocTagFieldStringMinLength:
+			greaterThanOrEqual, err := strconv.Atoi(schemaFieldKV[1])
+			if err != nil {
+				panic(fmt.Sprintf("prototype=%+v field=%s tag=%s value invalid, err=%v", prototype, field.Name, schemaFieldKV[0], err.Error()))
+			}
+			schemeRef.Value.MinLength = uint64(greaterThanOrEqual)
 
-				case DocTagFieldMinimum:
-					greaterThanOrEqual, err := strconv.ParseFloat(schemaFieldKV[1], 64)
-					if err != nil {
-						panic(fmt.Sprintf("prototype=%+v field=%s tag=%s value invalid, err=%v", prototype, field.Name, schemaFieldKV[0], err.Error()))
-					}
-					schemeRef.Value.Min = &greaterThanOrEqual
+		case DocTagFieldMinimum:
+			greaterThanOrEqual, err := strconv.ParseFloat(schemaFieldKV[1], 64)
+			if err != nil {
+				panic(fmt.Sprintf("prototype=%+v field=%s tag=%s value invalid, err=%v", prototype, field.Name, schemaFieldKV[0], err.Error()))
+			}
+			schemeRef.Value.Min = &greaterThanOrEqual
 
-				}
+		default:
+			s.applyDocTag(schemeRef.Value, prototype, field, schemaFieldKV[0], schemaFieldKV[1])
+		}
+	}
+	if schemeRef.Value.Discriminator != nil {
+		completeDiscriminator(schemeRef.Value)
+	}
+	return schemeRef, required
+}
+
+// applyDocTag applies the doc tags which are type checked against the kind of the field, the values are converted
+// to the kind of the field.
+func (s *Spec) applyDocTag(sch *openapi3.Schema, prototype interface{}, field reflect.StructField, key, val string) {
+	t := derefType(field.Type)
+	mustKind := func(ok bool) {
+		if !ok {
+			panic(fmt.Sprintf("prototype=%+v field=%s tag=%s not supported by kind=%s", prototype, field.Name, key, t.Kind()))
+		}
+	}
+	mustValue := func(v interface{}, err error) interface{} {
+		if err != nil {
+			panic(fmt.Sprintf("prototype=%+v field=%s tag=%s value invalid, err=%v", prototype, field.Name, key, err.Error()))
+		}
+		return v
+	}
+	switch key {
+	case DocTagFieldDefault:
+		if ref, exists := s.refs[val]; exists {
+			sch.Default = ref
+		} else {
+			sch.Default = mustValue(docTagValue(t, val))
+		}
+	case DocTagFieldExample:
+		if ref, exists := s.refs[val]; exists {
+			sch.Example = ref
+		} else {
+			sch.Example = mustValue(docTagValue(t, val))
+		}
+	case DocTagFieldTitle:
+		if ref, exists := s.refs[val]; exists {
+			sch.Title = ref.(string)
+		} else {
+			sch.Title = val
+		}
+	case DocTagFieldReadOnly:
+		sch.ReadOnly = true
+	case DocTagFieldWriteOnly:
+		sch.WriteOnly = true
+	case DocTagFieldDeprecated:
+		sch.Deprecated = true
+	case DocTagFieldMultipleOf:
+		mustKind(isNumberKind(t.Kind()))
+		multipleOf := mustValue(strconv.ParseFloat(val, 64)).(float64)
+		sch.MultipleOf = &multipleOf
+	case DocTagFieldExclusiveMin:
+		mustKind(isNumberKind(t.Kind()))
+		sch.ExclusiveMin = true
+	case DocTagFieldExclusiveMax:
+		mustKind(isNumberKind(t.Kind()))
+		sch.ExclusiveMax = true
+	case DocTagFieldUniqueItems:
+		mustKind(t.Kind() == reflect.Slice || t.Kind() == reflect.Array)
+		sch.UniqueItems = true
+	case DocTagFieldMinProperties:
+		mustKind(t.Kind() == reflect.Map || t.Kind() == reflect.Struct)
+		sch.MinProps = mustValue(strconv.ParseUint(val, 10, 64)).(uint64)
+	case DocTagFieldMaxProperties:
+		mustKind(t.Kind() == reflect.Map || t.Kind() == reflect.Struct)
+		maxProps := mustValue(strconv.ParseUint(val, 10, 64)).(uint64)
+		sch.MaxProps = &maxProps
+	case DocTagFieldOneOf, DocTagFieldAnyOf:
+		mustKind(t.Kind() == reflect.Interface)
+		s.compositionTag(sch, key, val)
+	case DocTagFieldDiscriminator:
+		mustKind(t.Kind() == reflect.Interface)
+		sch.Discriminator = &openapi3.Discriminator{PropertyName: val}
+	}
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// docTagValue converts the doc tag value to the type t, the items of a slice are separated by ";" like enum and
+// the other types are parsed as JSON.
+func docTagValue(t reflect.Type, val string) (interface{}, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return val, nil
+	case reflect.Bool:
+		return strconv.ParseBool(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(val, 10, t.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(val, 10, t.Bits())
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(val, t.Bits())
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, 0)
+		if val == "" {
+			return items, nil
+		}
+		for _, item := range strings.Split(val, ";") {
+			v, err := docTagValue(t.Elem(), item)
+			if err != nil {
+				return nil, err
 			}
-			objSch.Properties[fn] = schemeRef
+			items = append(items, v)
 		}
-		return openapi3.NewSchemaRef("", objSch)
+		return items, nil
+	default:
+		var v interface{}
+		err := json.Unmarshal([]byte(val), &v)
+		return v, err
 	}
+}
 
-	panic(fmt.Sprintf("prototype=%+v not supported", prototype))
+var componentNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9.\-_]+`)
+
+// componentSchemaRef registers the schema of the named type t under components/schemas once and returns a $ref to it.
+// The component is registered before build is called so that a recursive type refers to itself.
+func (s *Spec) componentSchemaRef(t reflect.Type, build func() *openapi3.Schema) *openapi3.SchemaRef {
+	if s.root.Components == nil {
+		s.root.Components = &openapi3.Components{}
+	}
+	if s.root.Components.Schemas == nil {
+		s.root.Components.Schemas = make(openapi3.Schemas)
+	}
+	if s.componentNames == nil {
+		s.componentNames = make(map[reflect.Type]string)
+	}
+	if name, exists := s.componentNames[t]; exists {
+		return openapi3.NewSchemaRef("#/components/schemas/"+name, s.root.Components.Schemas[name].Value)
+	}
+
+	name := strings.Trim(componentNameInvalidChars.ReplaceAllString(t.Name(), "_"), "_")
+	if _, exists := s.root.Components.Schemas[name]; exists {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	base := name
+	for i := 2; s.root.Components.Schemas[name] != nil; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+
+	sch := &openapi3.Schema{}
+	s.componentNames[t] = name
+	s.root.Components.Schemas[name] = openapi3.NewSchemaRef("", sch)
+	*sch = *build()
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, sch)
+}
+
+func hasSchemaModifier(kvs [][]string) bool {
+	for _, kv := range kvs {
+		if kv[0] != DocTagFieldRequired {
+			return true
+		}
+	}
+	return false
 }
 
 func parseDocTag(tagContent string) [][]string {
@@ -553,7 +1311,7 @@ func parseDocTag(tagContent string) [][]string {
 			}
 		}
 	}
-	if state == stateFieldKey && len(ret) == 0 {
+	if state == stateFieldKey {
 		ret = append(ret, []string{key, val})
 	} else if state != stateNextField {
 		panic("invalid doc tag, content=" + tagContent)
@@ -578,6 +1336,32 @@ func extractArray(arrayOrPtr interface{}) *valHolder {
 	if ret := extractOpenAPIType(arrayOrPtr, reflect.Slice); ret != nil {
 		return ret
 	}
+	if ret := extractOpenAPIType(arrayOrPtr, reflect.Array); ret != nil {
+		return ret
+	}
+	return nil
+}
+
+func extractBytes(bytesOrPtr interface{}) *valHolder {
+	if ret := extractOpenAPIType(bytesOrPtr, reflect.Slice); ret != nil && ret.Type.Elem().Kind() == reflect.Uint8 {
+		return ret
+	}
+	return nil
+}
+
+func extractUnsignedInteger(integerOrPtr interface{}) *valHolder {
+	for _, kind := range []reflect.Kind{reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64} {
+		if ret := extractOpenAPIType(integerOrPtr, kind); ret != nil {
+			return ret
+		}
+	}
+	return nil
+}
+
+func extractExactType(valOrValPtr interface{}, t reflect.Type) *valHolder {
+	if ret := extractOpenAPIType(valOrValPtr, t.Kind()); ret != nil && ret.Type == t {
+		return ret
+	}
 	return nil
 }
 
@@ -644,13 +1428,14 @@ func extractOpenAPIType(valOrValPtr interface{}, kind reflect.Kind) *valHolder {
 	t := reflect.TypeOf(valOrValPtr)
 	v := reflect.ValueOf(valOrValPtr)
 
-	if t.Kind() == reflect.Ptr {
-		if t.Elem().Kind() != kind {
-			return nil
-		}
+	// the value of a nil pointer is invalid, so that only the type is documented
+	for t.Kind() == reflect.Ptr {
 		t = t.Elem()
-		v = v.Elem()
-	} else if t.Kind() != kind {
+		if v.IsValid() {
+			v = v.Elem()
+		}
+	}
+	if t.Kind() != kind {
 		return nil
 	}
 	return &valHolder{
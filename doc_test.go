@@ -1,9 +1,14 @@
 package ginx
 
 import (
+	"context"
 	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
 )
 
 func TestDocHeader(t *testing.T) {
@@ -20,7 +25,7 @@ func TestDocHeader(t *testing.T) {
 			"bool":   true,
 		},
 	}
-	sch := extractSchema(testStruct)
+	sch := DefaultSpec.extractSchema(testStruct)
 	b, err := sch.MarshalJSON()
 	assertNil(t, err)
 	assertNotNil(t, b)
@@ -130,3 +135,332 @@ func TestParseDocTag(t *testing.T) {
 	assertEqual(t, ret[1][0], "desc")
 	assertEqual(t, ret[1][1], "#DescErrorCode")
 }
+
+type treeNode struct {
+	Value    string      `json:"value" doc:"required"`
+	Children []*treeNode `json:"children" doc:"desc(children)"`
+	Next     *treeNode   `json:"next" doc:"nullable desc(next sibling)"`
+}
+
+func TestExtractSchemaComponents(t *testing.T) {
+	spec := NewSpec("", "1.0.0", "")
+	sch := spec.extractSchema(struct {
+		Response  JSONResponse   `json:"response" doc:"required"`
+		Responses []JSONResponse `json:"responses" doc:"desc(responses)"`
+		Tree      treeNode       `json:"tree" doc:"desc(tree)"`
+	}{})
+	assertEqual(t, sch.Ref, "")
+	assertEqual(t, sch.Value.Properties["response"].Ref, "#/components/schemas/JSONResponse")
+	assertEqual(t, sch.Value.Properties["responses"].Value.Items.Ref, "#/components/schemas/JSONResponse")
+	assertEqual(t, sch.Value.Properties["tree"].Ref, "")
+	assertEqual(t, sch.Value.Properties["tree"].Value.Description, "tree")
+	assertEqual(t, sch.Value.Properties["tree"].Value.AllOf[0].Ref, "#/components/schemas/treeNode")
+	assertEqual(t, len(spec.Root().Components.Schemas), 2)
+
+	tree := spec.Root().Components.Schemas["treeNode"].Value
+	assertEqual(t, tree.Properties["children"].Value.Items.Ref, "#/components/schemas/treeNode")
+	assertEqual(t, tree.Properties["next"].Value.AllOf[0].Ref, "#/components/schemas/treeNode")
+	assertTrue(t, tree.Properties["next"].Value.Nullable)
+	assertEqual(t, spec.Root().Components.Schemas["JSONResponse"].Value.Description, "")
+
+	b, err := json.Marshal(spec.Root())
+	assertNil(t, err)
+	assertTrue(t, strings.Contains(string(b), `"children":{"description":"children","items":{"$ref":"#/components/schemas/treeNode"},"type":"array"}`))
+}
+
+func TestExtractSchemaComponentNameCollision(t *testing.T) {
+	spec := NewSpec("", "1.0.0", "")
+	assertEqual(t, spec.extractSchema(JSONResponse{}).Ref, "#/components/schemas/JSONResponse")
+	assertEqual(t, spec.extractSchema(&JSONResponse{FieldOne: "one"}).Ref, "#/components/schemas/JSONResponse")
+
+	type JSONResponse struct {
+		Field string `json:"field" doc:"required"`
+	}
+	assertEqual(t, spec.extractSchema(JSONResponse{}).Ref, "#/components/schemas/ginx.JSONResponse")
+	{
+		type JSONResponse struct {
+			Field int `json:"field" doc:"required"`
+		}
+		assertEqual(t, spec.extractSchema(JSONResponse{}).Ref, "#/components/schemas/ginx.JSONResponse2")
+	}
+	assertEqual(t, len(spec.Root().Components.Schemas), 3)
+}
+
+func TestDocPathInferPathParams(t *testing.T) {
+	spec := NewSpec("orgs description", "1.0.0", "orgs")
+	rg := spec.NewRouteGroup("/orgs")
+	rg.Get("/:%s/users/:%s", "org", "id").Doc().
+		Path(Path("id").Schema(1).Description("user id")).
+		Response("200", TextResponseBody("success"), "success")
+
+	op := spec.Root().Paths.Value("/orgs/{org}/users/{id}").Get
+	assertEqual(t, len(op.Parameters), 2)
+	org := op.Parameters.GetByInAndName(ParamPath, "org")
+	assertTrue(t, org.Required)
+	assertTrue(t, org.Schema.Value.Type.Is("string"))
+	id := op.Parameters.GetByInAndName(ParamPath, "id")
+	assertTrue(t, id.Schema.Value.Type.Is("integer"))
+	assertEqual(t, id.Description, "user id")
+	assertNil(t, spec.Root().Validate(context.Background()))
+}
+
+func TestDocPathUndeclaredPathParam(t *testing.T) {
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	NewSpec("", "1.0.0", "").NewRouteGroup("/users").Get("/:%s", "id").Doc().
+		Path(Path("userId").Schema(1))
+}
+
+func TestParseDocTagTrailingFlag(t *testing.T) {
+	ret := parseDocTag(`minimum(0) exclusiveMinimum`)
+	assertEqual(t, len(ret), 2)
+	assertEqual(t, ret[1][0], "exclusiveMinimum")
+}
+
+type docTagStruct struct {
+	ID       string            `json:"id" doc:"readOnly title(identifier)"`
+	Password string            `json:"password" doc:"writeOnly"`
+	Legacy   string            `json:"legacy" doc:"deprecated"`
+	Count    int               `json:"count" doc:"default(10) example(20) multipleOf(5) minimum(0) exclusiveMinimum"`
+	Ratio    float64           `json:"ratio" doc:"default(0.5) maximum(1) exclusiveMaximum"`
+	Enabled  *bool             `json:"enabled" doc:"default(true)"`
+	Tags     []string          `json:"tags" doc:"uniqueItems default(a;b)"`
+	Labels   map[string]string `json:"labels" doc:"minProperties(1) maxProperties(3) example({\"env\":\"prod\"})"`
+}
+
+func TestExtractSchemaDocTags(t *testing.T) {
+	spec := NewSpec("", "1.0.0", "")
+	sch := spec.extractSchema(struct {
+		Value docTagStruct `json:"value" doc:"required"`
+	}{}).Value.Properties["value"].Value
+	props := sch.Properties
+
+	assertTrue(t, props["id"].Value.ReadOnly)
+	assertEqual(t, props["id"].Value.Title, "identifier")
+	assertTrue(t, props["password"].Value.WriteOnly)
+	assertTrue(t, props["legacy"].Value.Deprecated)
+	assertEqual(t, props["count"].Value.Default, int64(10))
+	assertEqual(t, props["count"].Value.Example, int64(20))
+	assertEqual(t, *props["count"].Value.MultipleOf, float64(5))
+	assertTrue(t, props["count"].Value.ExclusiveMin)
+	assertEqual(t, props["ratio"].Value.Default, 0.5)
+	assertTrue(t, props["ratio"].Value.ExclusiveMax)
+	assertEqual(t, props["enabled"].Value.Default, true)
+	assertTrue(t, props["tags"].Value.UniqueItems)
+	assertEqual(t, len(props["tags"].Value.Default.([]interface{})), 2)
+	assertEqual(t, props["labels"].Value.MinProps, uint64(1))
+	assertEqual(t, *props["labels"].Value.MaxProps, uint64(3))
+	assertEqual(t, props["labels"].Value.Example.(map[string]interface{})["env"], "prod")
+}
+
+func TestExtractSchemaDocTagKindMismatch(t *testing.T) {
+	for _, prototype := range []interface{}{
+		struct {
+			Name string `json:"name" doc:"multipleOf(2)"`
+		}{},
+		struct {
+			Name string `json:"name" doc:"uniqueItems"`
+		}{},
+		struct {
+			Count int `json:"count" doc:"default(abc)"`
+		}{},
+	} {
+		func() {
+			defer func() {
+				assertTrue(t, recover() != nil)
+			}()
+			NewSpec("", "1.0.0", "").extractSchema(prototype)
+		}()
+	}
+}
+
+type enumStatus string
+
+func (enumStatus) EnumValues() []interface{} {
+	return []interface{}{"active", "inactive"}
+}
+
+type enumLevel int
+
+func (*enumLevel) EnumValues() []interface{} {
+	return []interface{}{1, 2, 3}
+}
+
+func TestExtractSchemaEnum(t *testing.T) {
+	spec := NewSpec("", "1.0.0", "")
+	sch := spec.extractSchema(struct {
+		Count   int          `json:"count" doc:"enum(1;2;3)"`
+		Ratio   float64      `json:"ratio" doc:"enum(0.5;1)"`
+		Codes   []int        `json:"codes" doc:"enum(1;2)"`
+		Status  enumStatus   `json:"status" doc:"required"`
+		Level   *enumLevel   `json:"level" doc:"desc(level)"`
+		Pointer *enumStatus  `json:"pointer" doc:"enum(active)"`
+		Names   []enumStatus `json:"names" doc:"desc(names)"`
+	}{}).Value
+
+	assertTrue(t, reflect.DeepEqual(sch.Properties["count"].Value.Enum, []interface{}{float64(1), float64(2), float64(3)}))
+	assertTrue(t, reflect.DeepEqual(sch.Properties["ratio"].Value.Enum, []interface{}{0.5, float64(1)}))
+	assertTrue(t, sch.Properties["codes"].Value.Enum == nil)
+	assertTrue(t, reflect.DeepEqual(sch.Properties["codes"].Value.Items.Value.Enum, []interface{}{float64(1), float64(2)}))
+	assertTrue(t, reflect.DeepEqual(sch.Properties["status"].Value.Enum, []interface{}{"active", "inactive"}))
+	assertTrue(t, reflect.DeepEqual(sch.Properties["level"].Value.Enum, []interface{}{float64(1), float64(2), float64(3)}))
+	assertTrue(t, reflect.DeepEqual(sch.Properties["pointer"].Value.Enum, []interface{}{"active"}))
+	assertTrue(t, reflect.DeepEqual(sch.Properties["names"].Value.Items.Value.Enum, []interface{}{"active", "inactive"}))
+	assertNil(t, openapi3.NewSchemaRef("", sch).Validate(context.Background()))
+	assertEqual(t, sch.Properties["count"].Value.Example, float64(1))
+	var body map[string]interface{}
+	assertNil(t, json.Unmarshal([]byte(`{"count": 2, "ratio": 0.5, "codes": [1], "status": "active", "level": 3}`), &body))
+	assertNil(t, sch.VisitJSON(body))
+	assertNil(t, json.Unmarshal([]byte(`{"count": 4}`), &body))
+	assertNotNil(t, sch.VisitJSON(body))
+}
+
+func TestExtractSchemaEnumRef(t *testing.T) {
+	spec := NewSpec("", "1.0.0", "")
+	spec.DefineRef("#levels", []interface{}{1, 2})
+	sch := spec.extractSchema(struct {
+		Level  int   `json:"level" doc:"enum(#levels)"`
+		Levels []int `json:"levels" doc:"enum(#levels)"`
+	}{Level: 1, Levels: []int{2}}).Value
+
+	assertTrue(t, reflect.DeepEqual(sch.Properties["level"].Value.Enum, []interface{}{float64(1), float64(2)}))
+	assertTrue(t, reflect.DeepEqual(sch.Properties["levels"].Value.Items.Value.Enum, []interface{}{float64(1), float64(2)}))
+	assertNil(t, openapi3.NewSchemaRef("", sch).Validate(context.Background()))
+	var body map[string]interface{}
+	assertNil(t, json.Unmarshal([]byte(`{"level": 2, "levels": [1, 2]}`), &body))
+	assertNil(t, sch.VisitJSON(body))
+	assertNil(t, json.Unmarshal([]byte(`{"level": 3}`), &body))
+	assertNotNil(t, sch.VisitJSON(body))
+}
+
+type typeTableStruct struct {
+	Count    uint            `json:"count" doc:"desc(count)"`
+	Size     uint64          `json:"size" doc:"desc(size)"`
+	Created  time.Time       `json:"created" doc:"desc(created)"`
+	Updated  *time.Time      `json:"updated" doc:"desc(updated)"`
+	Timeout  time.Duration   `json:"timeout" doc:"desc(timeout)"`
+	Data     []byte          `json:"data" doc:"desc(data)"`
+	Raw      json.RawMessage `json:"raw" doc:"desc(raw)"`
+	Any      interface{}     `json:"any" doc:"desc(any)"`
+	Anys     []interface{}   `json:"anys" doc:"desc(anys)"`
+	Point    [2]float64      `json:"point" doc:"desc(point)"`
+	Parent   *typeTableChild `json:"parent" doc:"desc(parent)"`
+	Done     chan struct{}   `json:"done" doc:"desc(done)"`
+	Callback func()          `json:"callback" doc:"desc(callback)"`
+}
+
+type typeTableChild struct {
+	Name string `json:"name" doc:"required"`
+}
+
+func TestExtractSchemaTypeTable(t *testing.T) {
+	spec := NewSpec("type table description", "1.0.0", "type table")
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sch := spec.extractSchema(struct {
+		Value typeTableStruct `json:"value" doc:"required"`
+	}{Value: typeTableStruct{Created: created, Data: []byte("hi"), Timeout: time.Second}}).Value.Properties["value"].Value
+	props := sch.Properties
+
+	for _, name := range []string{"count", "size"} {
+		assertTrue(t, props[name].Value.Type.Is("integer"))
+		assertEqual(t, *props[name].Value.Min, float64(0))
+	}
+	assertTrue(t, props["created"].Value.Type.Is("string"))
+	assertEqual(t, props["created"].Value.Format, "date-time")
+	assertEqual(t, props["created"].Value.Example, "2024-01-02T03:04:05Z")
+	assertEqual(t, props["updated"].Value.Format, "date-time")
+	assertTrue(t, props["timeout"].Value.Type.Is("integer"))
+	assertEqual(t, props["timeout"].Value.Example, int64(time.Second))
+	assertTrue(t, props["data"].Value.Type.Is("string"))
+	assertEqual(t, props["data"].Value.Format, "byte")
+	assertEqual(t, props["data"].Value.Example, "aGk=")
+	assertTrue(t, props["raw"].Value.IsEmpty())
+	assertTrue(t, props["any"].Value.IsEmpty())
+	assertTrue(t, props["anys"].Value.Items.Value.IsEmpty())
+	assertEqual(t, props["point"].Value.MinItems, uint64(2))
+	assertEqual(t, *props["point"].Value.MaxItems, uint64(2))
+	assertTrue(t, props["point"].Value.Items.Value.Type.Is("number"))
+	assertEqual(t, props["parent"].Value.AllOf[0].Ref, "#/components/schemas/typeTableChild")
+	assertTrue(t, props["done"] == nil)
+	assertTrue(t, props["callback"] == nil)
+	assertNil(t, spec.Root().Validate(context.Background()))
+}
+
+type embeddedBase struct {
+	ID string `json:"id" doc:"required"`
+}
+
+type embeddedAudit struct {
+	Author string `json:"author" doc:"desc(author)"`
+}
+
+func TestExtractSchemaFromType(t *testing.T) {
+	spec := NewSpec("", "1.0.0", "")
+	var nilScores *map[string]int
+	var nilNames **[]string
+	sch := spec.extractSchema(struct {
+		*embeddedBase
+		embeddedAudit
+		Children []typeTableChild          `json:"children" doc:"desc(children)"`
+		Scores   *map[string]int           `json:"scores" doc:"desc(scores)"`
+		Lookup   map[string]typeTableChild `json:"lookup" doc:"desc(lookup)"`
+		Names    **[]string                `json:"names" doc:"desc(names)"`
+		Values   []interface{}             `json:"values" doc:"desc(values)"`
+	}{
+		Scores: nilScores,
+		Lookup: map[string]typeTableChild{"a": {Name: "a"}},
+		Names:  nilNames,
+		Values: []interface{}{1, "two"},
+	}).Value
+	props := sch.Properties
+
+	assertEqual(t, sch.Required[0], "id")
+	assertNotNil(t, props["author"])
+	assertEqual(t, props["children"].Value.Items.Ref, "#/components/schemas/typeTableChild")
+	assertTrue(t, props["scores"].Value.Type.Is("object"))
+	assertTrue(t, props["scores"].Value.AdditionalProperties.Schema.Value.Type.Is("integer"))
+	assertEqual(t, props["lookup"].Value.AdditionalProperties.Schema.Ref, "#/components/schemas/typeTableChild")
+	assertEqual(t, props["lookup"].Value.Example.(map[string]interface{})["a"].(map[string]interface{})["name"], "a")
+	assertTrue(t, props["names"].Value.Items.Value.Type.Is("string"))
+	assertTrue(t, props["values"].Value.Items.Value.IsEmpty())
+	assertNil(t, sch.VisitJSON(map[string]interface{}{"id": "1", "scores": map[string]interface{}{"a": 1}}))
+	assertNotNil(t, sch.VisitJSON(map[string]interface{}{"id": "1", "scores": map[string]interface{}{"a": "one"}}))
+}
+
+type embeddedShadowed struct {
+	ID    string `json:"id" doc:"required"`
+	Name  string `json:"name" doc:"required"`
+	Label string
+}
+
+type embeddedConflict struct {
+	Label string
+	Title int
+}
+
+type embeddedTagged struct {
+	Title string `json:"Title" doc:"required"`
+}
+
+func TestExtractSchemaEmbeddedShadowing(t *testing.T) {
+	spec := NewSpec("", "1.0.0", "")
+	prototype := struct {
+		ID int `json:"id"`
+		embeddedShadowed
+		embeddedConflict
+		embeddedTagged
+	}{ID: 5}
+	sch := spec.extractSchema(prototype).Value
+
+	assertTrue(t, sch.Properties["id"].Value.Type.Is("integer"))
+	assertTrue(t, reflect.DeepEqual(sch.Required, []string{"name", "Title"}))
+	assertTrue(t, sch.Properties["Label"] == nil)
+	assertTrue(t, sch.Properties["Title"].Value.Type.Is("string"))
+	b, err := json.Marshal(prototype)
+	assertNil(t, err)
+	var encoded map[string]interface{}
+	assertNil(t, json.Unmarshal(b, &encoded))
+	assertEqual(t, len(encoded), len(sch.Properties))
+	assertNil(t, sch.VisitJSON(encoded))
+}
@@ -0,0 +1,187 @@
+package ginx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	LocationBody     = "body"
+	LocationSecurity = "security"
+	LocationResponse = "response"
+	LocationRoute    = "route"
+)
+
+// Violation is a single mismatch between the request and the spec. Location is one of ParamPath, ParamQuery,
+// ParamHeader, ParamCookie, LocationBody, LocationSecurity, LocationResponse and LocationRoute, Name is the name of the parameter and Pointer is
+// the JSON pointer to the field within the parameter or the body.
+type Violation struct {
+	Location string `json:"location"`
+	Name     string `json:"name,omitempty"`
+	Pointer  string `json:"pointer,omitempty"`
+	Keyword  string `json:"keyword,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ValidationError is passed to the validation error handlers, it wraps the error of openapi3filter.
+type ValidationError struct {
+	Status     int
+	Violations []Violation
+	Err        error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// NewValidationError converts the error of openapi3filter into a ValidationError, it returns nil if err is nil.
+func NewValidationError(err error) *ValidationError {
+	return newValidationError(err, nil)
+}
+
+func newValidationError(err error, customSchemaErrorMessage func(err *openapi3.SchemaError) string) *ValidationError {
+	if err == nil {
+		return nil
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr
+	}
+	validationErr = &ValidationError{Status: http.StatusBadRequest, Err: err}
+	validationErr.collect(err, LocationBody, "", customSchemaErrorMessage)
+	return validationErr
+}
+
+func (e *ValidationError) collect(err error, location, name string, customSchemaErrorMessage func(err *openapi3.SchemaError) string) {
+	switch v := err.(type) {
+	case openapi3.MultiError:
+		for _, item := range v {
+			e.collect(item, location, name, customSchemaErrorMessage)
+		}
+	case *openapi3filter.SecurityRequirementsError:
+		e.Status = http.StatusUnauthorized
+		for _, item := range v.Errors {
+			e.collect(item, LocationSecurity, "", customSchemaErrorMessage)
+		}
+		if len(v.Errors) == 0 {
+			e.add(Violation{Location: LocationSecurity, Message: v.Error()})
+		}
+	case *openapi3filter.RequestError:
+		if v.Parameter != nil {
+			location, name = v.Parameter.In, v.Parameter.Name
+		} else if v.RequestBody != nil {
+			location, name = LocationBody, ""
+		}
+		if v.Err == nil {
+			e.add(Violation{Location: location, Name: name, Message: v.Reason})
+		} else {
+			e.collect(v.Err, location, name, customSchemaErrorMessage)
+		}
+	case *openapi3filter.ResponseError:
+		e.Status = http.StatusInternalServerError
+		if v.Err == nil {
+			e.add(Violation{Location: LocationResponse, Message: v.Reason})
+		} else {
+			e.collect(v.Err, LocationResponse, "", customSchemaErrorMessage)
+		}
+	case *routers.RouteError:
+		e.Status = http.StatusNotFound
+		if v.Reason == routers.ErrMethodNotAllowed.Error() {
+			e.Status = http.StatusMethodNotAllowed
+		}
+		e.add(Violation{Location: LocationRoute, Message: v.Reason})
+	case *openapi3.SchemaError:
+		message := v.Reason
+		if v.Origin != nil {
+			message = v.Origin.Error()
+		} else if message == "" {
+			message = fmt.Sprintf("doesn't match schema %q", v.SchemaField)
+		}
+		if customSchemaErrorMessage != nil {
+			if customMessage := customSchemaErrorMessage(v); customMessage != "" {
+				message = customMessage
+			}
+		}
+		e.add(Violation{Location: location, Name: name, Pointer: jsonPointer(v.JSONPointer()), Keyword: v.SchemaField, Message: message})
+	case *openapi3filter.ParseError:
+		var path []string
+		for _, p := range v.Path() {
+			path = append(path, fmt.Sprint(p))
+		}
+		message := v.Reason
+		if message == "" && v.RootCause() != nil {
+			message = v.RootCause().Error()
+		}
+		e.add(Violation{Location: location, Name: name, Pointer: jsonPointer(path), Message: message})
+	default:
+		keyword := ""
+		if errors.Is(err, openapi3filter.ErrInvalidRequired) {
+			keyword = DocTagFieldRequired
+		} else if errors.Is(err, openapi3filter.ErrInvalidEmptyValue) {
+			keyword = "allowEmptyValue"
+		}
+		e.add(Violation{Location: location, Name: name, Keyword: keyword, Message: err.Error()})
+	}
+}
+
+func (e *ValidationError) add(violation Violation) {
+	e.Violations = append(e.Violations, violation)
+}
+
+func jsonPointer(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var sb strings.Builder
+	for _, p := range path {
+		sb.WriteString("/")
+		sb.WriteString(escaper.Replace(p))
+	}
+	return sb.String()
+}
+
+// Problem is the RFC 7807 problem details rendered by ProblemValidationErrorHandler.
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Errors   []Violation `json:"errors,omitempty"`
+}
+
+// ProblemValidationErrorHandler is a validation error handler which renders the ValidationError as
+// application/problem+json.
+func ProblemValidationErrorHandler(c *gin.Context, err error) {
+	validationErr := NewValidationError(err)
+	if validationErr == nil {
+		return
+	}
+	detail := "the request does not match the API specification"
+	if validationErr.Status == http.StatusNotFound || validationErr.Status == http.StatusMethodNotAllowed {
+		detail = "the request does not match any documented operation"
+	} else if validationErr.Status >= http.StatusInternalServerError {
+		detail = "the response does not match the API specification"
+	}
+	c.Header("Content-Type", "application/problem+json")
+	c.AbortWithStatusJSON(validationErr.Status, Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(validationErr.Status),
+		Status:   validationErr.Status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Errors:   validationErr.Violations,
+	})
+}
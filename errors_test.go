@@ -0,0 +1,182 @@
+package ginx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
+)
+
+type problemRequest struct {
+	Name  string        `json:"name" doc:"required minLength(2)"`
+	Items []problemItem `json:"items" doc:"desc(items)"`
+}
+
+type problemItem struct {
+	Count int `json:"count" doc:"required minimum(1)"`
+}
+
+type problemAPI struct {
+	spec *Spec
+}
+
+func (a problemAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/problem")
+	rg.
+		Post("/items/:%s", "id").
+		To(func(ctx *gin.Context) {
+			ctx.String(http.StatusOK, "success")
+		}).
+		Doc().
+		Path(Path("id").Schema(1)).
+		Query(Query("limit").Schema(1)).
+		RequestBody(JSONRequestBody(problemRequest{Name: "name", Items: []problemItem{{Count: 1}}})).
+		Response("200", TextResponseBody("success"), "success")
+	rg.
+		Get("/secure").
+		To(func(ctx *gin.Context) {
+			ctx.String(http.StatusOK, "success")
+		}).
+		Doc().
+		Security("bearer").
+		Response("200", TextResponseBody("success"), "success")
+	return rg
+}
+
+func newProblemEngine() *gin.Engine {
+	spec := NewSpec("problem description", "1.0.0", "problem")
+	spec.DefineSecurityScheme("bearer", HTTPBearer("JWT"))
+	g := gin.New()
+	spec.UseSwaggerUI(g, "/apidoc")
+	spec.UseValidatorWithOptions(g, ProblemValidationErrorHandler, &ValidatorOptions{
+		Authenticators: map[string]Authenticator{
+			"bearer": func(c *gin.Context, input *openapi3filter.AuthenticationInput) (interface{}, error) {
+				return nil, errors.New("invalid token")
+			},
+		},
+	})
+	spec.AddAPI(g, problemAPI{spec: spec})
+	return g
+}
+
+func serveProblem(t *testing.T, g *gin.Engine, req *http.Request) Problem {
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	assertEqual(t, w.Header().Get("Content-Type"), "application/problem+json")
+	var problem Problem
+	assertNil(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assertEqual(t, problem.Status, w.Code)
+	assertEqual(t, problem.Title, http.StatusText(w.Code))
+	assertEqual(t, problem.Instance, req.URL.Path)
+	return problem
+}
+
+func TestProblemValidationErrorHandler(t *testing.T) {
+	g := newProblemEngine()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/problem/items/1?limit=1", bytes.NewBufferString(`{"name": "name"}`))
+	req.Header.Set("Content-Type", "application/json")
+	g.ServeHTTP(w, req)
+	assertEqual(t, w.Code, http.StatusOK)
+
+	problem := serveProblem(t, g, httptest.NewRequest(http.MethodPost, "/problem/items/abc?limit=1", nil))
+	assertEqual(t, problem.Status, http.StatusBadRequest)
+	assertEqual(t, len(problem.Errors), 1)
+	assertEqual(t, problem.Errors[0].Location, ParamPath)
+	assertEqual(t, problem.Errors[0].Name, "id")
+
+	problem = serveProblem(t, g, httptest.NewRequest(http.MethodPost, "/problem/items/1", nil))
+	assertEqual(t, problem.Errors[0].Location, ParamQuery)
+	assertEqual(t, problem.Errors[0].Name, "limit")
+	assertEqual(t, problem.Errors[0].Keyword, "required")
+
+	req = httptest.NewRequest(http.MethodPost, "/problem/items/1?limit=1", bytes.NewBufferString(`{"name": "name", "items": [{"count": 0}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	problem = serveProblem(t, g, req)
+	assertEqual(t, problem.Errors[0].Location, LocationBody)
+	assertEqual(t, problem.Errors[0].Pointer, "/items/0/count")
+	assertEqual(t, problem.Errors[0].Keyword, "minimum")
+	assertTrue(t, problem.Errors[0].Message != "")
+
+	problem = serveProblem(t, g, httptest.NewRequest(http.MethodGet, "/problem/secure", nil))
+	assertEqual(t, problem.Status, http.StatusUnauthorized)
+	assertEqual(t, problem.Errors[0].Location, LocationSecurity)
+	assertEqual(t, problem.Errors[0].Message, "invalid token")
+}
+
+func TestNewValidationError(t *testing.T) {
+	assertTrue(t, NewValidationError(nil) == nil)
+
+	cause := errors.New("cause")
+	validationErr := NewValidationError(openapi3.MultiError{
+		&openapi3filter.RequestError{Parameter: &openapi3.Parameter{Name: "X-Test", In: ParamHeader}, Err: openapi3filter.ErrInvalidRequired},
+		&openapi3filter.RequestError{RequestBody: &openapi3.RequestBody{}, Err: &openapi3filter.ParseError{Kind: openapi3filter.KindInvalidFormat, Cause: cause}},
+		cause,
+	})
+	assertEqual(t, validationErr.Status, http.StatusBadRequest)
+	assertEqual(t, len(validationErr.Violations), 3)
+	assertEqual(t, validationErr.Violations[0], Violation{Location: ParamHeader, Name: "X-Test", Keyword: "required", Message: openapi3filter.ErrInvalidRequired.Error()})
+	assertEqual(t, validationErr.Violations[1], Violation{Location: LocationBody, Message: "cause"})
+	assertEqual(t, validationErr.Violations[2], Violation{Location: LocationBody, Message: "cause"})
+	assertTrue(t, errors.Is(validationErr, cause))
+	assertTrue(t, NewValidationError(validationErr) == validationErr)
+	assertEqual(t, jsonPointer([]string{"a/b", "c~d"}), "/a~1b/c~0d")
+}
+
+func TestValidatorOptions(t *testing.T) {
+	newEngine := func(options *ValidatorOptions) *gin.Engine {
+		spec := NewSpec("problem description", "1.0.0", "problem")
+		spec.DefineSecurityScheme("bearer", HTTPBearer("JWT"))
+		g := gin.New()
+		spec.UseSwaggerUI(g, "/apidoc")
+		spec.UseValidatorWithOptions(g, ProblemValidationErrorHandler, options)
+		spec.AddAPI(g, problemAPI{spec: spec})
+		return g
+	}
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/problem/items/abc?limit=abc", bytes.NewBufferString(`{"name": "n", "items": [{"count": 0}, {}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	problem := serveProblem(t, newEngine(nil), newRequest())
+	assertEqual(t, len(problem.Errors), 1)
+
+	problem = serveProblem(t, newEngine(&ValidatorOptions{MultiError: true}), newRequest())
+	assertEqual(t, len(problem.Errors), 5)
+	assertEqual(t, problem.Errors[0].Name, "id")
+	assertEqual(t, problem.Errors[1].Name, "limit")
+	pointers := make(map[string]string)
+	for _, violation := range problem.Errors[2:] {
+		assertEqual(t, violation.Location, LocationBody)
+		pointers[violation.Pointer] = violation.Keyword
+	}
+	assertEqual(t, pointers["/name"], "minLength")
+	assertEqual(t, pointers["/items/0/count"], "minimum")
+	assertEqual(t, pointers["/items/1/count"], "required")
+
+	problem = serveProblem(t, newEngine(&ValidatorOptions{
+		MultiError:                true,
+		ExcludeRequestBody:        true,
+		ExcludeRequestQueryParams: true,
+	}), newRequest())
+	assertEqual(t, len(problem.Errors), 1)
+	assertEqual(t, problem.Errors[0].Name, "id")
+
+	req := httptest.NewRequest(http.MethodPost, "/problem/items/1", bytes.NewBufferString(`{"name": "n"}`))
+	req.Header.Set("Content-Type", "application/json")
+	problem = serveProblem(t, newEngine(&ValidatorOptions{
+		ExcludeRequestQueryParams: true,
+		CustomSchemaErrorMessage: func(err *openapi3.SchemaError) string {
+			return "invalid " + err.SchemaField
+		},
+	}), req)
+	assertEqual(t, problem.Errors[0].Message, "invalid minLength")
+}
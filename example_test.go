@@ -2,13 +2,19 @@ package ginx
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"io"
 	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 )
 
@@ -24,6 +30,7 @@ func NewExampleAPI() exampleAPIIF {
 }
 
 type exampleAPI struct {
+	spec *Spec
 }
 
 func init() {
@@ -68,7 +75,11 @@ type JSONResponse struct {
 
 func (e exampleAPI) RouteGroup() *RouteGroup {
 	docTag := "example"
-	rg := NewRouteGroup("/test")
+	spec := e.spec
+	if spec == nil {
+		spec = DefaultSpec
+	}
+	rg := spec.NewRouteGroup("/test")
 
 	rg.
 		Put("/json/:%s", "path").
@@ -280,3 +291,514 @@ func TestExampleAPI_FormUrlencodedTest(t *testing.T) {
 	assertNil(t, err)
 	assertEqual(t, resp.StatusCode, http.StatusOK)
 }
+
+type adminAPI struct {
+	spec *Spec
+}
+
+func (a adminAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/admin")
+	rg.
+		Get("/users/:%s", "id").
+		To(func(ctx *gin.Context) {
+			ctx.String(http.StatusOK, ctx.Param("id"))
+		}).
+		Doc().
+		Summary("get user").
+		Path(Path("id").Schema("id")).
+		Query(Query("verbose").Schema(true)).
+		Response("200", TextResponseBody("id"), "success")
+	return rg
+}
+
+func TestSpec_Independent(t *testing.T) {
+	public := NewSpec("public description", "1.0.0", "public")
+	admin := NewSpec("admin description", "1.0.0", "admin")
+
+	publicEngine := gin.New()
+	public.UseSwaggerUI(publicEngine, "/apidoc")
+	public.UseValidator(publicEngine, func(ctx *gin.Context, err error) {
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	})
+	public.AddAPI(publicEngine, exampleAPI{spec: public})
+
+	adminEngine := gin.New()
+	admin.UseSwaggerUI(adminEngine, "/apidoc")
+	admin.UseValidator(adminEngine, func(ctx *gin.Context, err error) {
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	})
+	admin.AddAPI(adminEngine, adminAPI{spec: admin})
+
+	assertNotNil(t, public.Root().Paths.Value("/test/json/{path}"))
+	assertTrue(t, public.Root().Paths.Value("/admin/users/{id}") == nil)
+	assertNotNil(t, admin.Root().Paths.Value("/admin/users/{id}"))
+	assertTrue(t, admin.Root().Paths.Value("/test/json/{path}") == nil)
+
+	w := httptest.NewRecorder()
+	adminEngine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users/1?verbose=true", nil))
+	assertEqual(t, w.Code, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	adminEngine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users/1?verbose=abc", nil))
+	assertEqual(t, w.Code, http.StatusBadRequest)
+
+	w = httptest.NewRecorder()
+	adminEngine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/apidoc/swagger.json", nil))
+	assertEqual(t, w.Code, http.StatusOK)
+	assertTrue(t, strings.Contains(w.Body.String(), `"title":"admin"`))
+	assertTrue(t, !strings.Contains(w.Body.String(), "/test/json"))
+}
+
+func TestSpec_AddAPIFromAnotherSpec(t *testing.T) {
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	NewSpec("", "1.0.0", "").AddAPI(gin.New(), adminAPI{spec: NewSpec("", "1.0.0", "")})
+}
+
+type responseAPI struct {
+	spec *Spec
+}
+
+func (a responseAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/response")
+	rg.
+		Get("/valid").
+		To(func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, JSONResponse{FieldOne: "one", FieldTwo: true})
+		}).
+		Doc().
+		Response("200", JSONResponseBody(JSONResponse{FieldOne: "one"}), "success")
+	rg.
+		Get("/status").
+		To(func(ctx *gin.Context) {
+			ctx.JSON(http.StatusCreated, JSONResponse{FieldOne: "one", FieldTwo: true})
+		}).
+		Doc().
+		Response("200", JSONResponseBody(JSONResponse{FieldOne: "one"}), "success")
+	rg.
+		Get("/schema").
+		To(func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, map[string]interface{}{"field1": 1})
+		}).
+		Doc().
+		Response("200", JSONResponseBody(JSONResponse{FieldOne: "one"}), "success")
+	rg.
+		Get("/content-type").
+		To(func(ctx *gin.Context) {
+			ctx.Header("Location", "/response/valid")
+			ctx.SetCookie("session", "abc", 0, "/", "", false, true)
+			ctx.String(http.StatusOK, "one")
+		}).
+		Doc().
+		Response("200", JSONResponseBody(JSONResponse{FieldOne: "one"}), "success")
+	rg.
+		Get("/panic").
+		To(func(ctx *gin.Context) {
+			panic("handler failed")
+		}).
+		Doc().
+		Response("200", JSONResponseBody(JSONResponse{FieldOne: "one"}), "success")
+	return rg
+}
+
+func TestSpec_UseResponseValidator(t *testing.T) {
+	spec := NewSpec("response description", "1.0.0", "response")
+	var errs []error
+	g := gin.New()
+	spec.UseResponseValidator(g, ResponseValidationLog, func(ctx *gin.Context, err error) {
+		errs = append(errs, err)
+	})
+	spec.AddAPI(g, responseAPI{spec: spec})
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/response/valid", nil))
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Body.String(), `{"field1":"one","field2":true}`)
+	assertEqual(t, len(errs), 0)
+
+	for _, p := range []string{"/response/status", "/response/schema", "/response/content-type"} {
+		w = httptest.NewRecorder()
+		g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+		assertTrue(t, w.Code != http.StatusInternalServerError)
+		assertTrue(t, w.Body.Len() > 0)
+	}
+	assertEqual(t, len(errs), 3)
+}
+
+type headersAPI struct {
+	spec *Spec
+}
+
+func (a headersAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/headers")
+	rg.
+		Post("/users").
+		To(func(ctx *gin.Context) {
+			if ctx.Query("location") != "false" {
+				ctx.Header("Location", "/headers/users/1")
+			}
+			ctx.Header("X-RateLimit-Remaining", ctx.DefaultQuery("remaining", "10"))
+			if ctx.GetHeader("Accept") == "text/csv" {
+				ctx.Data(http.StatusCreated, "text/csv", []byte("id,name\n1,one"))
+				return
+			}
+			ctx.JSON(http.StatusCreated, JSONResponse{FieldOne: "one"})
+		}).
+		Doc().
+		OperationID("createUser").
+		Response("201", JSONResponseBody(JSONResponse{FieldOne: "one"}).
+			Also("text/csv", "id,name\n1,one").
+			Header(Header("Location").Schema("/headers/users/1").Description("the created user")).
+			Header(Header("X-RateLimit-Remaining").Schema(10).Required(false)).
+			Link("getUser", Link("getUser").Parameter("id", "$response.body#/field1").Description("the created user")), "created")
+	rg.
+		Get("/users/:%s", "id").
+		To(func(ctx *gin.Context) {}).
+		Doc().
+		OperationID("getUser").
+		Response("200", TextResponseBody("success"), "success")
+	return rg
+}
+
+func TestSpec_ResponseHeadersAndLinks(t *testing.T) {
+	spec := NewSpec("headers description", "1.0.0", "headers")
+	var errs []error
+	g := gin.New()
+	spec.UseResponseValidator(g, ResponseValidationLog, func(ctx *gin.Context, err error) {
+		errs = append(errs, err)
+	})
+	assertNil(t, spec.AddAPI(g, headersAPI{spec: spec}))
+	assertNil(t, spec.ValidateSpec(context.Background()))
+
+	resp := spec.Root().Paths.Value("/headers/users").Post.Responses.Status(http.StatusCreated).Value
+	assertNotNil(t, resp.Content.Get("application/json"))
+	assertEqual(t, resp.Content.Get("text/csv").Schema.Value.Example, "id,name\n1,one")
+	location := resp.Headers["Location"].Value
+	assertTrue(t, location.Required)
+	assertEqual(t, location.Name, "")
+	assertEqual(t, location.Description, "the created user")
+	assertTrue(t, !resp.Headers["X-RateLimit-Remaining"].Value.Required)
+	assertEqual(t, resp.Links["getUser"].Value.OperationID, "getUser")
+	assertEqual(t, resp.Links["getUser"].Value.Parameters["id"], "$response.body#/field1")
+
+	for _, accept := range []string{"application/json", "text/csv"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/headers/users", nil)
+		req.Header.Set("Accept", accept)
+		g.ServeHTTP(w, req)
+		assertEqual(t, w.Code, http.StatusCreated)
+	}
+	assertEqual(t, len(errs), 0)
+
+	for _, query := range []string{"location=false", "remaining=many"} {
+		g.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/headers/users?"+query, nil))
+	}
+	assertEqual(t, len(errs), 2)
+}
+
+func TestSpec_UseResponseValidatorReplace(t *testing.T) {
+	spec := NewSpec("response description", "1.0.0", "response")
+	g := gin.New()
+	spec.UseResponseValidator(g, ResponseValidationReplace, nil)
+	spec.AddAPI(g, responseAPI{spec: spec})
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/response/valid", nil))
+	assertEqual(t, w.Code, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/response/content-type", nil))
+	assertEqual(t, w.Code, http.StatusInternalServerError)
+	assertEqual(t, w.Header().Get("Content-Type"), "application/json; charset=utf-8")
+	assertEqual(t, w.Body.String(), `{"error":"response validation failed"}`)
+	assertEqual(t, w.Header().Get("Location"), "")
+	assertEqual(t, w.Header().Get("Set-Cookie"), "")
+}
+
+func TestSpec_UseResponseValidatorRecovery(t *testing.T) {
+	spec := NewSpec("response description", "1.0.0", "response")
+	g := gin.New()
+	g.Use(gin.RecoveryWithWriter(io.Discard))
+	spec.UseResponseValidator(g, ResponseValidationReplace, nil)
+	spec.AddAPI(g, responseAPI{spec: spec})
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/response/panic", nil))
+	assertEqual(t, w.Code, http.StatusInternalServerError)
+}
+
+func TestSpec_UseResponseValidatorHandlerResponds(t *testing.T) {
+	for _, mode := range []ResponseValidationMode{ResponseValidationLog, ResponseValidationReplace} {
+		spec := NewSpec("response description", "1.0.0", "response")
+		g := gin.New()
+		spec.UseResponseValidator(g, mode, ProblemValidationErrorHandler)
+		spec.AddAPI(g, responseAPI{spec: spec})
+
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/response/schema", nil))
+		assertEqual(t, w.Code, http.StatusInternalServerError)
+		var problem Problem
+		assertNil(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assertEqual(t, problem.Errors[0].Location, LocationResponse)
+	}
+}
+
+func TestSpec_UseResponseValidatorPanic(t *testing.T) {
+	spec := NewSpec("response description", "1.0.0", "response")
+	g := gin.New()
+	spec.UseResponseValidator(g, ResponseValidationPanic, func(ctx *gin.Context, err error) {})
+	spec.AddAPI(g, responseAPI{spec: spec})
+
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	g.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/response/schema", nil))
+}
+
+type validationAPI struct {
+	spec *Spec
+}
+
+func (a validationAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/validation")
+	handler := func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "success")
+	}
+	rg.Get("/checked").To(handler).Doc().
+		Query(Query("limit").Schema(1).Required(true)).
+		Response("200", TextResponseBody("success"), "success")
+	rg.Get("/skipped").To(handler).SkipValidation().Doc().
+		Query(Query("limit").Schema(1).Required(true)).
+		Response("200", TextResponseBody("success"), "success")
+	rg.Get("/excluded/checked").To(handler).Doc().
+		Query(Query("limit").Schema(1).Required(true)).
+		Response("200", TextResponseBody("success"), "success")
+	rg.Get("/undocumented").To(handler)
+	rg.Delete("/checked").To(handler)
+	return rg
+}
+
+func TestSpec_UseValidatorUndocumentedRoute(t *testing.T) {
+	serve := func(policy UndocumentedRoutePolicy, method, path string) *httptest.ResponseRecorder {
+		spec := NewSpec("validation description", "1.0.0", "validation")
+		g := gin.New()
+		spec.UseValidatorWithOptions(g, ProblemValidationErrorHandler, &ValidatorOptions{
+			UndocumentedRoute: policy,
+			ExcludePaths:      []string{"/validation/excluded"},
+		})
+		spec.AddAPI(g, validationAPI{spec: spec})
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	assertEqual(t, serve(UndocumentedRouteAllow, http.MethodGet, "/validation/checked").Code, http.StatusBadRequest)
+	assertEqual(t, serve(UndocumentedRouteAllow, http.MethodGet, "/validation/skipped").Code, http.StatusOK)
+	assertEqual(t, serve(UndocumentedRouteAllow, http.MethodGet, "/validation/excluded/checked").Code, http.StatusOK)
+	assertEqual(t, serve(UndocumentedRouteAllow, http.MethodGet, "/validation/undocumented").Code, http.StatusOK)
+	assertEqual(t, serve(UndocumentedRouteReject, http.MethodGet, "/validation/undocumented").Code, http.StatusNotFound)
+	assertEqual(t, serve(UndocumentedRouteReject, http.MethodDelete, "/validation/checked").Code, http.StatusMethodNotAllowed)
+	assertEqual(t, serve(UndocumentedRouteReject, http.MethodGet, "/validation/skipped").Code, http.StatusOK)
+
+	var buf bytes.Buffer
+	defaultErrorWriter := gin.DefaultErrorWriter
+	gin.DefaultErrorWriter = &buf
+	defer func() {
+		gin.DefaultErrorWriter = defaultErrorWriter
+	}()
+	assertEqual(t, serve(UndocumentedRouteWarn, http.MethodGet, "/validation/undocumented").Code, http.StatusOK)
+	assertTrue(t, strings.Contains(buf.String(), "path=/validation/undocumented"))
+}
+
+func TestSpec_UseValidatorHandlerNotAborting(t *testing.T) {
+	spec := NewSpec("validation description", "1.0.0", "validation")
+	var errs []error
+	g := gin.New()
+	spec.UseValidator(g, func(ctx *gin.Context, err error) {
+		errs = append(errs, err)
+	})
+	spec.AddAPI(g, validationAPI{spec: spec})
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/validation/checked?limit=1", nil))
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, len(errs), 0)
+
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/validation/checked?limit=abc", nil))
+	assertEqual(t, w.Code, http.StatusBadRequest)
+	assertEqual(t, w.Body.String(), "")
+	assertEqual(t, len(errs), 1)
+	assertNotNil(t, errs[0])
+}
+
+type invalidDocAPI struct {
+	spec *Spec
+}
+
+func (a invalidDocAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/invalid")
+	rg.Post("/tags").To(func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "success")
+	}).Doc().
+		// the nil slice is an example of null, which doesn't match the array schema
+		RequestBody(JSONRequestBody(struct {
+			Tags []string `json:"tags" doc:"desc(tags)"`
+		}{})).
+		Response("200", TextResponseBody("success"), "success")
+	return rg
+}
+
+func TestSpec_AddAPIReturnsRouterError(t *testing.T) {
+	spec := NewSpec("validation description", "1.0.0", "validation")
+	g := gin.New()
+	spec.UseValidator(g, func(ctx *gin.Context, err error) {})
+	assertNil(t, spec.AddAPI(g, validationAPI{spec: spec}))
+	assertNotNil(t, spec.AddAPI(g, invalidDocAPI{spec: spec}))
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/validation/checked", nil))
+	assertEqual(t, w.Code, http.StatusInternalServerError)
+}
+
+func TestSpec_UseValidatorRouterRebuild(t *testing.T) {
+	spec := NewSpec("validation description", "1.0.0", "validation")
+	g := gin.New()
+	spec.UseValidatorWithOptions(g, ProblemValidationErrorHandler, &ValidatorOptions{UndocumentedRoute: UndocumentedRouteReject})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users/1", nil))
+			assertEqual(t, w.Code, http.StatusNotFound)
+		}()
+	}
+	wg.Wait()
+
+	assertNil(t, spec.AddAPI(g, adminAPI{spec: spec}))
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users/1?verbose=true", nil))
+	assertEqual(t, w.Code, http.StatusOK)
+}
+
+type usersAPI struct {
+	spec *Spec
+}
+
+func (a usersAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/users")
+	rg.Get("/:%s", "id").To(func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, ctx.Param("id"))
+	}).Doc().
+		Path(Path("id").Schema(1)).
+		Response("200", TextResponseBody("1"), "success")
+	rg.Get("/me").To(func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "me")
+	}).Doc().
+		Response("200", TextResponseBody("me"), "success")
+	return rg
+}
+
+func TestSpec_UseValidatorResolvesGinRoute(t *testing.T) {
+	spec := NewSpec("users description", "1.0.0", "users")
+	g := gin.New()
+	spec.UseValidator(g, ProblemValidationErrorHandler)
+	assertNil(t, spec.AddAPI(g, usersAPI{spec: spec}))
+
+	for path, code := range map[string]int{
+		"/users/1":   http.StatusOK,
+		"/users/me":  http.StatusOK,
+		"/users/abc": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assertEqual(t, w.Code, code)
+	}
+}
+
+type filesAPI struct {
+	spec *Spec
+}
+
+func (a filesAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/files")
+	handler := func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, ctx.Param("filepath"))
+	}
+	rg.Get("/*filepath").To(handler).Doc().
+		Response("200", TextResponseBody("a/b.txt"), "success")
+	rg.Put("/*filepath").To(handler).Doc().
+		Path(Path("filepath").Schema("/a/b.txt").Description("file path")).
+		Query(Query("force").Schema(true)).
+		Response("200", TextResponseBody("a/b.txt"), "success")
+	return rg
+}
+
+func TestSpec_WildcardPath(t *testing.T) {
+	spec := NewSpec("files description", "1.0.0", "files")
+	g := gin.New()
+	spec.UseValidator(g, ProblemValidationErrorHandler)
+	assertNil(t, spec.AddAPI(g, filesAPI{spec: spec}))
+
+	pathItem := spec.Root().Paths.Value("/files/{filepath}")
+	assertNotNil(t, pathItem)
+	for _, op := range []*openapi3.Operation{pathItem.Get, pathItem.Put} {
+		param := op.Parameters.GetByInAndName(ParamPath, "filepath")
+		assertNotNil(t, param)
+		assertTrue(t, param.Required)
+		assertEqual(t, param.Extensions[ExtensionWildcard], true)
+	}
+	assertEqual(t, len(pathItem.Put.Parameters), 2)
+	assertEqual(t, pathItem.Put.Parameters.GetByInAndName(ParamPath, "filepath").Description, "file path")
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/a/b.txt", nil))
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Body.String(), "/a/b.txt")
+
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/", nil))
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Body.String(), "/")
+	assertEqual(t, pathItem.Get.Parameters.GetByInAndName(ParamPath, "filepath").Schema.Value.Pattern, "^/")
+
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/files/a/b.txt?force=true", nil))
+	assertEqual(t, w.Code, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/files/a/b.txt", nil))
+	assertEqual(t, w.Code, http.StatusBadRequest)
+}
+
+func TestSpec_UseValidatorAfterAddAPI(t *testing.T) {
+	spec := NewSpec("validation description", "1.0.0", "validation")
+	g := gin.New()
+	assertNil(t, spec.AddAPI(g, validationAPI{spec: spec}))
+	spec.UseValidator(gin.New(), func(ctx *gin.Context, err error) {})
+
+	assertNotNil(t, spec.AddAPI(g, invalidDocAPI{spec: spec}))
+	for _, use := range []func(){
+		func() { spec.UseValidator(gin.New(), func(ctx *gin.Context, err error) {}) },
+		func() { spec.UseResponseValidator(gin.New(), ResponseValidationLog, nil) },
+	} {
+		func() {
+			defer func() {
+				assertTrue(t, recover() != nil)
+			}()
+			use()
+		}()
+	}
+}
@@ -1,12 +1,11 @@
 package ginx
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3filter"
-	"github.com/getkin/kin-openapi/routers"
-	"github.com/getkin/kin-openapi/routers/legacy"
 	"github.com/gin-gonic/gin"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -20,8 +19,6 @@ import (
 	"strings"
 )
 
-var swaggerPathPrefix string
-
 func UseOpenTracing(engine *gin.Engine, tracer opentracing.Tracer) {
 	engine.Use(func(c *gin.Context) {
 		carrier := opentracing.HTTPHeadersCarrier(c.Request.Header)
@@ -40,21 +37,119 @@ func UseOpenTracing(engine *gin.Engine, tracer opentracing.Tracer) {
 }
 
 func UseSwaggerUI(engine *gin.Engine, swaggerPath string) {
+	DefaultSpec.UseSwaggerUI(engine, swaggerPath)
+}
+
+func (s *Spec) UseSwaggerUI(engine *gin.Engine, swaggerPath string) {
 	if !strings.HasPrefix(swaggerPath, "/") {
 		swaggerPath = "/" + swaggerPath
 	}
 	engine.Use(func(context *gin.Context) {
 		if context.Request.URL.Path == path.Join(swaggerPath, "swagger.json") && context.Request.Method == http.MethodGet {
-			context.AbortWithStatusJSON(http.StatusOK, DocRoot)
+			context.AbortWithStatusJSON(http.StatusOK, s.root)
 		}
 		context.Next()
 	})
 	engine.StaticFS(swaggerPath, AssetFile())
-	swaggerPathPrefix = swaggerPath
+	s.swaggerPathPrefix = swaggerPath
 }
 
 func UseValidator(engine *gin.Engine, validationErrorHandler func(*gin.Context, error), opts ...openapi3.ValidationOption) {
-	var router routers.Router
+	DefaultSpec.UseValidator(engine, validationErrorHandler, opts...)
+}
+
+func UseValidatorWithOptions(engine *gin.Engine, validationErrorHandler func(*gin.Context, error), options *ValidatorOptions) {
+	DefaultSpec.UseValidatorWithOptions(engine, validationErrorHandler, options)
+}
+
+type ValidatorOptions struct {
+	// Authenticators verify the credentials of the security scheme keyed by the scheme name. When it is nil the
+	// security requirements are for documentation only and the credentials are not verified.
+	Authenticators map[string]Authenticator
+	// DocValidationOptions are applied when the OpenAPI document is validated on building the router.
+	DocValidationOptions []openapi3.ValidationOption
+	// MultiError collects all the violations instead of stopping at the first one.
+	MultiError bool
+	// ExcludeRequestBody skips the validation of the request body.
+	ExcludeRequestBody bool
+	// ExcludeRequestQueryParams skips the validation of the query parameters.
+	ExcludeRequestQueryParams bool
+	// IncludeResponseStatus makes the response validator fail on a status code which is not documented.
+	IncludeResponseStatus bool
+	// CustomSchemaErrorMessage overrides the message of a schema violation when it returns a non-empty string.
+	CustomSchemaErrorMessage func(err *openapi3.SchemaError) string
+	// UndocumentedRoute decides what the request validator does with a request which doesn't match any documented
+	// operation.
+	UndocumentedRoute UndocumentedRoutePolicy
+	// ExcludePaths are the request paths which are not validated, each entry matches the path itself and every path
+	// under it. The path of the Swagger UI is always excluded.
+	ExcludePaths []string
+}
+
+type UndocumentedRoutePolicy int
+
+const (
+	// UndocumentedRouteAllow passes the request to the next handlers without validation.
+	UndocumentedRouteAllow UndocumentedRoutePolicy = iota
+	// UndocumentedRouteWarn writes the request to gin.DefaultErrorWriter and passes it without validation.
+	UndocumentedRouteWarn
+	// UndocumentedRouteReject passes a ValidationError with status 404 or 405 to the validation error handler.
+	UndocumentedRouteReject
+)
+
+func (o *ValidatorOptions) filterOptions() *openapi3filter.Options {
+	filterOptions := &openapi3filter.Options{
+		MultiError:                o.MultiError,
+		ExcludeRequestBody:        o.ExcludeRequestBody,
+		ExcludeRequestQueryParams: o.ExcludeRequestQueryParams,
+		IncludeResponseStatus:     o.IncludeResponseStatus,
+	}
+	if o.CustomSchemaErrorMessage != nil {
+		filterOptions.WithCustomSchemaErrorFunc(o.CustomSchemaErrorMessage)
+	}
+	return filterOptions
+}
+
+func (o *ValidatorOptions) validationError(err error) *ValidationError {
+	return newValidationError(err, o.CustomSchemaErrorMessage)
+}
+
+func (s *Spec) skipValidation(c *gin.Context, excludePaths []string) bool {
+	if s.swaggerPathPrefix != "" && hasPathPrefix(c.Request.URL.Path, s.swaggerPathPrefix) {
+		return true
+	}
+	for _, excludePath := range excludePaths {
+		if hasPathPrefix(c.Request.URL.Path, excludePath) {
+			return true
+		}
+	}
+	return s.skipValidationRoutes[routeKey(c.Request.Method, c.FullPath())]
+}
+
+// hasPathPrefix reports whether p is prefix or under it, "/apidoc" matches "/apidoc/index.html" but not "/apidocs".
+func hasPathPrefix(p, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+func routeKey(method, httpPath string) string {
+	return method + " " + httpPath
+}
+
+// UseValidator validates every request against the documented operations. It should be called before AddAPI, which
+// returns the error of an invalid document, when it is called afterwards an invalid document panics.
+func (s *Spec) UseValidator(engine *gin.Engine, validationErrorHandler func(*gin.Context, error), opts ...openapi3.ValidationOption) {
+	s.UseValidatorWithOptions(engine, validationErrorHandler, &ValidatorOptions{DocValidationOptions: opts})
+}
+
+func (s *Spec) UseValidatorWithOptions(engine *gin.Engine, validationErrorHandler func(*gin.Context, error), options *ValidatorOptions) {
+	if options == nil {
+		options = &ValidatorOptions{}
+	}
+	for name := range options.Authenticators {
+		s.mustHaveSecurityScheme(name)
+	}
+	router := s.newDocRouter(options.DocValidationOptions...)
 	decodeBody := func(body io.Reader, header http.Header, schema *openapi3.SchemaRef, encFn openapi3filter.EncodingFn) (interface{}, error) {
 		data, err := ioutil.ReadAll(body)
 		if err != nil {
@@ -171,42 +266,245 @@ func UseValidator(engine *gin.Engine, validationErrorHandler func(*gin.Context,
 	})
 
 	engine.Use(func(c *gin.Context) {
-		reqUrl := c.Request.URL
-
-		if strings.HasPrefix(reqUrl.Path, swaggerPathPrefix) {
+		if s.skipValidation(c, options.ExcludePaths) {
 			return
 		}
 
-		if router == nil {
-			r, err := legacy.NewRouter(DocRoot, opts...)
-			if err != nil {
-				panic(err)
-			} else {
-				router = r
+		table, err := router.get()
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		route, pathParams, err := table.find(c)
+		if err != nil {
+			switch options.UndocumentedRoute {
+			case UndocumentedRouteWarn:
+				_, _ = fmt.Fprintf(gin.DefaultErrorWriter, "[GINX] undocumented route, method=%s path=%s\n", c.Request.Method, c.Request.URL.Path)
+			case UndocumentedRouteReject:
+				validationErr := options.validationError(err)
+				validationErrorHandler(c, validationErr)
+				if !c.IsAborted() {
+					c.AbortWithStatus(validationErr.Status)
+				}
 			}
+			return
 		}
-		route, pathParams, _ := router.FindRoute(c.Request)
 
+		var principal interface{}
+		filterOptions := options.filterOptions()
+		filterOptions.AuthenticationFunc = options.authenticationFunc(c, &principal)
 		requestValidationInput := &openapi3filter.RequestValidationInput{
 			Request:    c.Request,
 			PathParams: pathParams,
 			Route:      route,
+			Options:    filterOptions,
 		}
 
-		if err := openapi3filter.ValidateRequest(c, requestValidationInput); err != nil {
-			validationErrorHandler(c, err)
-		} else {
-			validationErrorHandler(c, nil)
+		err = openapi3filter.ValidateRequest(c, requestValidationInput)
+		// a scheme may succeed within a requirement that fails, so the principal is stored only when the security
+		// requirements are satisfied
+		var securityErr *openapi3filter.SecurityRequirementsError
+		if principal != nil && !errors.As(err, &securityErr) {
+			c.Set(PrincipalContextKey, principal)
+		}
+		if err != nil {
+			validationErr := options.validationError(err)
+			validationErrorHandler(c, validationErr)
+			if !c.IsAborted() {
+				c.AbortWithStatus(validationErr.Status)
+			}
+		}
+	})
+}
+
+type ResponseValidationMode int
+
+const (
+	// ResponseValidationLog reports the mismatch and sends the original response.
+	ResponseValidationLog ResponseValidationMode = iota
+	// ResponseValidationReplace reports the mismatch and replaces the response with a 500.
+	ResponseValidationReplace
+	// ResponseValidationPanic reports the mismatch and panics, intended for tests.
+	ResponseValidationPanic
+)
+
+// bufferedResponseWriter holds the response in memory until it has been validated.
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedResponseWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(data string) (int, error) {
+	w.written = true
+	return w.body.WriteString(data)
+}
+
+func (w *bufferedResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedResponseWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedResponseWriter) Written() bool {
+	return w.written
+}
+
+func (w *bufferedResponseWriter) Flush() {
+}
+
+func (w *bufferedResponseWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+	}
+}
+
+func UseResponseValidator(engine *gin.Engine, mode ResponseValidationMode, responseValidationErrorHandler func(*gin.Context, error), opts ...openapi3.ValidationOption) {
+	DefaultSpec.UseResponseValidator(engine, mode, responseValidationErrorHandler, opts...)
+}
+
+func UseResponseValidatorWithOptions(engine *gin.Engine, mode ResponseValidationMode, responseValidationErrorHandler func(*gin.Context, error), options *ValidatorOptions) {
+	DefaultSpec.UseResponseValidatorWithOptions(engine, mode, responseValidationErrorHandler, options)
+}
+
+// UseResponseValidator buffers every response of a documented route and validates it against the documented
+// responses, the responseValidationErrorHandler is notified on mismatch before mode is applied.
+// A nil responseValidationErrorHandler writes the mismatch to gin.DefaultErrorWriter. Like UseValidator, it should be
+// called before AddAPI.
+func (s *Spec) UseResponseValidator(engine *gin.Engine, mode ResponseValidationMode, responseValidationErrorHandler func(*gin.Context, error), opts ...openapi3.ValidationOption) {
+	s.UseResponseValidatorWithOptions(engine, mode, responseValidationErrorHandler, &ValidatorOptions{
+		DocValidationOptions:  opts,
+		IncludeResponseStatus: true,
+	})
+}
+
+func (s *Spec) UseResponseValidatorWithOptions(engine *gin.Engine, mode ResponseValidationMode, responseValidationErrorHandler func(*gin.Context, error), options *ValidatorOptions) {
+	if options == nil {
+		options = &ValidatorOptions{}
+	}
+	if responseValidationErrorHandler == nil {
+		responseValidationErrorHandler = func(c *gin.Context, err error) {
+			_, _ = fmt.Fprintf(gin.DefaultErrorWriter, "[GINX] response validation failed, method=%s path=%s err=%v\n", c.Request.Method, c.Request.URL.Path, err)
 		}
+	}
+	router := s.newDocRouter(options.DocValidationOptions...)
+
+	engine.Use(func(c *gin.Context) {
+		if s.skipValidation(c, options.ExcludePaths) {
+			return
+		}
+		table, err := router.get()
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		route, pathParams, err := table.find(c)
+		if err != nil {
+			return
+		}
+
+		w := &bufferedResponseWriter{ResponseWriter: c.Writer, status: c.Writer.Status()}
+		header := c.Writer.Header().Clone()
+		c.Writer = w
+		completed := false
+		defer func() {
+			if !completed {
+				// the handler has panicked, the response goes out as it would without the validator so that
+				// gin.Recovery registered in front of it can still respond
+				c.Writer = w.ResponseWriter
+				w.flush()
+			}
+		}()
 		c.Next()
+		completed = true
+		c.Writer = w.ResponseWriter
+		aborted := c.IsAborted()
+
+		responseValidationInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: &openapi3filter.RequestValidationInput{
+				Request:    c.Request,
+				PathParams: pathParams,
+				Route:      route,
+			},
+			Status:  w.status,
+			Header:  w.Header(),
+			Options: options.filterOptions(),
+		}
+		responseValidationInput.SetBodyBytes(w.body.Bytes())
+
+		if err := openapi3filter.ValidateResponse(c, responseValidationInput); err != nil {
+			responseValidationErrorHandler(c, options.validationError(err))
+			if mode == ResponseValidationPanic {
+				panic(fmt.Sprintf("response validation failed, method=%s path=%s err=%v", c.Request.Method, c.Request.URL.Path, err))
+			}
+			if c.Writer.Written() || (!aborted && c.IsAborted()) {
+				// the error handler has responded by itself, the buffered response is dropped
+				return
+			}
+			if mode == ResponseValidationReplace {
+				// the headers set by the handler, e.g. Location or Set-Cookie, don't belong to the replacement
+				for k := range c.Writer.Header() {
+					delete(c.Writer.Header(), k)
+				}
+				for k, v := range header {
+					c.Writer.Header()[k] = v
+				}
+				c.AbortWithStatusJSON(http.StatusInternalServerError, map[string]interface{}{
+					"error": "response validation failed",
+				})
+				return
+			}
+		}
+		w.flush()
 	})
 }
 
-func AddAPI(engine *gin.Engine, apiArgs ...API) {
+func AddAPI(engine *gin.Engine, apiArgs ...API) error {
+	return DefaultSpec.AddAPI(engine, apiArgs...)
+}
+
+// AddAPI registers the routes of apiArgs to engine and rebuilds the routers of the validators, the error of building
+// them, e.g. an invalid document, is returned.
+func (s *Spec) AddAPI(engine *gin.Engine, apiArgs ...API) error {
 	for _, api := range apiArgs {
-		rg := api.RouteGroup().getRoutes()
-		for _, r := range rg {
+		rg := api.RouteGroup()
+		if rg.spec != s {
+			panic(fmt.Sprintf("route group basePath=%s belongs to another spec", rg.basePath))
+		}
+		for _, r := range rg.getRoutes() {
+			if r.skipValidation {
+				if s.skipValidationRoutes == nil {
+					s.skipValidationRoutes = make(map[string]bool)
+				}
+				s.skipValidationRoutes[routeKey(strings.ToUpper(r.httpMethod), r.httpPath)] = true
+			}
 			engine.Handle(strings.ToUpper(r.httpMethod), r.httpPath, r.handlers...)
 		}
 	}
+	return s.buildRouters()
 }
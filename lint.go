@@ -0,0 +1,342 @@
+package ginx
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SpecError is a problem of the document found by ValidateSpec, Location is the JSON pointer to where it is found,
+// e.g. #/paths/~1users~1{id}/get/responses/200.
+type SpecError struct {
+	Location string
+	Message  string
+}
+
+func (e *SpecError) Error() string {
+	return e.Location + ": " + e.Message
+}
+
+// SpecErrors aggregates every problem found by ValidateSpec.
+type SpecErrors []*SpecError
+
+func (e SpecErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func ValidateSpec(ctx context.Context, opts ...openapi3.ValidationOption) error {
+	return DefaultSpec.ValidateSpec(ctx, opts...)
+}
+
+// ValidateSpec validates the document by openapi3 and the lint rules of ginx, which catch duplicate operationIds,
+// path parameters not matching the path template, invalid patterns, enum values not matching the schema type,
+// responses without description and links to undefined operations. It returns SpecErrors, or nil when no problem is found.
+func (s *Spec) ValidateSpec(ctx context.Context, opts ...openapi3.ValidationOption) error {
+	l := &linter{}
+	l.validate(openapi3.WithValidationOptions(ctx, opts...), s.root)
+	l.lintPaths(s.root.Paths)
+	if s.root.Components != nil {
+		for _, name := range sortedKeys(s.root.Components.Schemas) {
+			l.lintSchema([]string{"components", "schemas", name}, s.root.Components.Schemas[name])
+		}
+	}
+	l.dedupe()
+	if len(l.errs) == 0 {
+		// the problems between the parts, e.g. conflicting path templates, are only found by the whole document
+		if err := s.root.Validate(ctx, opts...); err != nil {
+			l.report(nil, "%v", err)
+		}
+	}
+	if len(l.errs) == 0 {
+		return nil
+	}
+	return l.errs
+}
+
+// validate runs the validation of openapi3 part by part, so that every problem is located by its own JSON pointer
+// and one problem doesn't hide the others. An operation is validated as a whole only when its parts are valid.
+func (l *linter) validate(ctx context.Context, doc *openapi3.T) {
+	check := func(location []string, err error) {
+		if err != nil {
+			l.report(location, "%v", err)
+		}
+	}
+	if doc.Info == nil {
+		l.report([]string{"info"}, "must be an object")
+	} else {
+		check([]string{"info"}, doc.Info.Validate(ctx))
+	}
+	if doc.Components != nil {
+		for _, name := range sortedKeys(doc.Components.Schemas) {
+			check([]string{"components", "schemas", name}, doc.Components.Schemas[name].Validate(ctx))
+		}
+		for _, name := range sortedKeys(doc.Components.SecuritySchemes) {
+			check([]string{"components", "securitySchemes", name}, doc.Components.SecuritySchemes[name].Validate(ctx))
+		}
+	}
+	if doc.Paths != nil {
+		for _, p := range doc.Paths.InMatchingOrder() {
+			pathItem := doc.Paths.Value(p)
+			for i, param := range pathItem.Parameters {
+				check([]string{"paths", p, "parameters", fmt.Sprint(i)}, param.Validate(ctx))
+			}
+			operations := pathItem.Operations()
+			for _, method := range sortedKeys(operations) {
+				op := operations[method]
+				location := []string{"paths", p, strings.ToLower(method)}
+				n := len(l.errs)
+				for i, param := range op.Parameters {
+					check(at(location, "parameters", fmt.Sprint(i)), param.Validate(ctx))
+				}
+				if op.RequestBody != nil {
+					check(at(location, "requestBody"), op.RequestBody.Validate(ctx))
+				}
+				if op.Responses != nil {
+					for _, status := range sortedKeys(op.Responses.Map()) {
+						check(at(location, "responses", status), op.Responses.Value(status).Validate(ctx))
+					}
+				}
+				if len(l.errs) == n {
+					check(location, op.Validate(ctx))
+				}
+			}
+		}
+	}
+	if doc.Security != nil {
+		check([]string{"security"}, doc.Security.Validate(ctx))
+	}
+	if doc.Servers != nil {
+		check([]string{"servers"}, doc.Servers.Validate(ctx))
+	}
+	if doc.Tags != nil {
+		check([]string{"tags"}, doc.Tags.Validate(ctx))
+	}
+}
+
+var pathTemplatePattern = regexp.MustCompile(`\{([^/}]+)\}`)
+
+type linter struct {
+	errs SpecErrors
+}
+
+func (l *linter) report(location []string, format string, args ...interface{}) {
+	l.errs = append(l.errs, &SpecError{Location: "#" + jsonPointer(location), Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) lintPaths(paths *openapi3.Paths) {
+	if paths == nil {
+		return
+	}
+	operationIDs := make(map[string]string)
+	type link struct {
+		location    []string
+		operationID string
+	}
+	var links []link
+	for _, p := range paths.InMatchingOrder() {
+		pathItem := paths.Value(p)
+		templated := make(map[string]bool)
+		for _, m := range pathTemplatePattern.FindAllStringSubmatch(p, -1) {
+			templated[m[1]] = true
+		}
+		operations := pathItem.Operations()
+		for _, method := range sortedKeys(operations) {
+			op := operations[method]
+			location := []string{"paths", p, strings.ToLower(method)}
+			if op.OperationID != "" {
+				if first, exists := operationIDs[op.OperationID]; exists {
+					l.report(at(location, "operationId"), "operationId=%s duplicates the one of %s", op.OperationID, first)
+				} else {
+					operationIDs[op.OperationID] = method + " " + p
+				}
+			}
+
+			declared := make(map[string]bool)
+			for i, params := range []openapi3.Parameters{pathItem.Parameters, op.Parameters} {
+				paramsLocation := at(location, "parameters")
+				if i == 0 {
+					paramsLocation = []string{"paths", p, "parameters"}
+				}
+				for j, param := range params {
+					if param.Value == nil {
+						continue
+					}
+					paramLocation := at(paramsLocation, fmt.Sprint(j))
+					if param.Value.In == ParamPath {
+						declared[param.Value.Name] = true
+						if !templated[param.Value.Name] {
+							l.report(paramLocation, "path parameter=%s is not in the path template", param.Value.Name)
+						}
+					}
+					l.lintSchema(at(paramLocation, "schema"), param.Value.Schema)
+				}
+			}
+			for _, name := range sortedKeys(templated) {
+				if !declared[name] {
+					l.report(location, "path parameter=%s of the path template is not declared", name)
+				}
+			}
+
+			if op.RequestBody != nil && op.RequestBody.Value != nil {
+				l.lintContent(at(location, "requestBody", "content"), op.RequestBody.Value.Content)
+			}
+			if op.Responses != nil {
+				for _, status := range sortedKeys(op.Responses.Map()) {
+					resp := op.Responses.Value(status)
+					if resp == nil || resp.Ref != "" || resp.Value == nil {
+						continue
+					}
+					respLocation := at(location, "responses", status)
+					if resp.Value.Description == nil || *resp.Value.Description == "" {
+						l.report(respLocation, "response has no description")
+					}
+					l.lintContent(at(respLocation, "content"), resp.Value.Content)
+					for _, name := range sortedKeys(resp.Value.Headers) {
+						if header := resp.Value.Headers[name]; header.Value != nil {
+							l.lintSchema(at(respLocation, "headers", name, "schema"), header.Value.Schema)
+						}
+					}
+					for _, name := range sortedKeys(resp.Value.Links) {
+						if ref := resp.Value.Links[name]; ref.Value != nil && ref.Value.OperationID != "" {
+							links = append(links, link{location: at(respLocation, "links", name, "operationId"), operationID: ref.Value.OperationID})
+						}
+					}
+				}
+			}
+		}
+	}
+	// a link may refer to an operation of a later path
+	for _, link := range links {
+		if _, exists := operationIDs[link.operationID]; !exists {
+			l.report(link.location, "operationId=%s of the link doesn't match any operation", link.operationID)
+		}
+	}
+}
+
+func (l *linter) lintContent(location []string, content openapi3.Content) {
+	for _, mediaType := range sortedKeys(content) {
+		l.lintSchema(at(location, mediaType, "schema"), content[mediaType].Schema)
+	}
+}
+
+// lintSchema doesn't follow $ref, the referenced components are linted by their own location.
+func (l *linter) lintSchema(location []string, schemaRef *openapi3.SchemaRef) {
+	if schemaRef == nil || schemaRef.Ref != "" || schemaRef.Value == nil {
+		return
+	}
+	schema := schemaRef.Value
+	if schema.Pattern != "" {
+		if _, err := regexp.Compile(schema.Pattern); err != nil {
+			l.report(at(location, "pattern"), "invalid pattern %q: %v", schema.Pattern, err)
+		}
+	}
+	for i, value := range schema.Enum {
+		if !matchSchemaType(schema.Type, value) {
+			l.report(at(location, "enum", fmt.Sprint(i)), "enum value %v doesn't match the type %v", value, schema.Type.Slice())
+		}
+	}
+
+	l.lintSchema(at(location, "items"), schema.Items)
+	for _, name := range sortedKeys(schema.Properties) {
+		l.lintSchema(at(location, "properties", name), schema.Properties[name])
+	}
+	l.lintSchema(at(location, "additionalProperties"), schema.AdditionalProperties.Schema)
+	l.lintSchema(at(location, "not"), schema.Not)
+	for i, ref := range schema.AllOf {
+		l.lintSchema(at(location, "allOf", fmt.Sprint(i)), ref)
+	}
+	for i, ref := range schema.AnyOf {
+		l.lintSchema(at(location, "anyOf", fmt.Sprint(i)), ref)
+	}
+	for i, ref := range schema.OneOf {
+		l.lintSchema(at(location, "oneOf", fmt.Sprint(i)), ref)
+	}
+}
+
+// dedupe drops a problem which is reported again at a more precise location, e.g. an invalid pattern found by
+// openapi3 at the schema and by the lint rules at its pattern, or the problem of a component found again through the
+// $ref of an operation.
+func (l *linter) dedupe() {
+	var errs SpecErrors
+	for _, err := range l.errs {
+		duplicated := false
+		for _, other := range l.errs {
+			if other == err || other.Location == err.Location || !strings.Contains(other.Message, err.Message) {
+				continue
+			}
+			if strings.HasPrefix(other.Location, err.Location+"/") ||
+				(strings.HasPrefix(other.Location, "#/components/") && !strings.HasPrefix(err.Location, "#/components/")) {
+				duplicated = true
+				break
+			}
+		}
+		if !duplicated {
+			errs = append(errs, err)
+		}
+	}
+	l.errs = errs
+}
+
+// at returns a copy of location with keys appended, so that the siblings don't share the backing array.
+func at(location []string, keys ...string) []string {
+	return append(append(make([]string, 0, len(location)+len(keys)), location...), keys...)
+}
+
+func matchSchemaType(types *openapi3.Types, value interface{}) bool {
+	if types == nil || len(*types) == 0 {
+		return true
+	}
+	for _, t := range *types {
+		switch v := value.(type) {
+		case nil:
+			return true
+		case string:
+			if t == openapi3.TypeString {
+				return true
+			}
+		case bool:
+			if t == openapi3.TypeBoolean {
+				return true
+			}
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			if t == openapi3.TypeInteger || t == openapi3.TypeNumber {
+				return true
+			}
+		case float32:
+			if t == openapi3.TypeNumber || (t == openapi3.TypeInteger && math.Trunc(float64(v)) == float64(v)) {
+				return true
+			}
+		case float64:
+			if t == openapi3.TypeNumber || (t == openapi3.TypeInteger && math.Trunc(v) == v) {
+				return true
+			}
+		case []interface{}:
+			if t == openapi3.TypeArray {
+				return true
+			}
+		case map[string]interface{}:
+			if t == openapi3.TypeObject {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,62 @@
+package ginx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type lintRequest struct {
+	Code string `json:"code" doc:"required pattern(^[A-Z)"`
+}
+
+type lintAPI struct {
+	spec *Spec
+}
+
+func (a lintAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/lint")
+	handler := func(ctx *gin.Context) {}
+	rg.Get("/users").To(handler).Doc().
+		OperationID("listUsers").
+		Query(Query("sort").Schema("name").Enum("name", 1)).
+		Query(Query("page").Schema(1).Example("first")).
+		Response("200", TextResponseBody("success"), "")
+	rg.Post("/users").To(handler).Doc().
+		OperationID("listUsers").
+		RequestBody(JSONRequestBody(lintRequest{Code: "A"})).
+		Response("200", TextResponseBody("success"), "success")
+	d := rg.Get("/users/:%s", "id").To(handler).Doc().
+		Response("200", TextResponseBody("success").Link("owner", Link("getOwner")), "success")
+	d.operation.Parameters = nil
+	return rg
+}
+
+func TestSpec_ValidateSpec(t *testing.T) {
+	spec := NewSpec("lint description", "1.0.0", "lint")
+	assertNil(t, spec.ValidateSpec(context.Background()))
+
+	spec.DefineSecurityScheme("key", APIKey("body", "key"))
+	spec.AddAPI(gin.New(), lintAPI{spec: spec})
+	err := spec.ValidateSpec(context.Background())
+	var specErrs SpecErrors
+	assertTrue(t, errors.As(err, &specErrs))
+
+	messages := make(map[string]string)
+	for _, specErr := range specErrs {
+		messages[specErr.Location] = specErr.Message
+	}
+	assertEqual(t, messages["#/paths/~1lint~1users/post/operationId"], "operationId=listUsers duplicates the one of GET /lint/users")
+	assertEqual(t, messages["#/paths/~1lint~1users/get/parameters/0/schema/enum/1"], "enum value 1 doesn't match the type [string]")
+	assertEqual(t, messages["#/paths/~1lint~1users/get/responses/200"], "response has no description")
+	assertEqual(t, messages["#/paths/~1lint~1users~1{id}/get"], "path parameter=id of the path template is not declared")
+	assertEqual(t, messages["#/paths/~1lint~1users~1{id}/get/responses/200/links/owner/operationId"], "operationId=getOwner of the link doesn't match any operation")
+	assertTrue(t, messages["#/components/schemas/lintRequest/properties/code/pattern"] != "")
+	assertTrue(t, messages["#/paths/~1lint~1users/get/parameters/1"] != "")
+	assertTrue(t, messages["#/components/securitySchemes/key"] != "")
+	assertEqual(t, messages["#/paths/~1lint~1users/post/requestBody"], "")
+	assertEqual(t, messages["#/components/schemas/lintRequest"], "")
+	assertEqual(t, messages["#"], "")
+}
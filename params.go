@@ -0,0 +1,119 @@
+package ginx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// paramTags are the struct tags which bind a field from the request, in the order they are looked up, gin has no
+// binding of the cookie tag but it documents the cookie parameters all the same.
+var paramTags = []struct {
+	tag string
+	in  string
+}{
+	{"path", ParamPath},
+	{"uri", ParamPath},
+	{"header", ParamHeader},
+	{"cookie", ParamCookie},
+	{"query", ParamQuery},
+	{"form", ParamQuery},
+}
+
+// Params documents the fields of the struct prototype as the parameters of the operation, a field is a path parameter
+// by the path or uri tag, a header by the header tag, a cookie by the cookie tag and a query parameter by the query or
+// form tag. The doc tag of a field has the same grammar as the one of a schema, and the required doc tag or the
+// required binding makes the parameter required.
+func (d *docPath) Params(prototype interface{}) *docPath {
+	vh := extractStruct(prototype)
+	if vh == nil {
+		panic(fmt.Sprintf("prototype=%+v of params must be a struct", prototype))
+	}
+	if !vh.Value.IsValid() {
+		vh.Value = reflect.Zero(vh.Type)
+	}
+	d.structParams(prototype, vh)
+	return d
+}
+
+func (d *docPath) structParams(prototype interface{}, vh *valHolder) {
+	for i := 0; i < vh.Type.NumField(); i++ {
+		field := vh.Type.Field(i)
+		val := vh.Value.Field(i)
+		if field.Anonymous && derefType(field.Type).Kind() == reflect.Struct {
+			embedded := &valHolder{Type: derefType(field.Type), Value: val}
+			for embedded.Value.Kind() == reflect.Ptr {
+				if embedded.Value.IsNil() {
+					embedded.Value = reflect.Zero(embedded.Type)
+				} else {
+					embedded.Value = embedded.Value.Elem()
+				}
+			}
+			d.structParams(prototype, embedded)
+			continue
+		}
+		in, name := paramLocation(field)
+		if in == "" || field.PkgPath != "" {
+			continue
+		}
+		d.addParam(d.spec.fieldParam(prototype, field, val, in, name))
+	}
+}
+
+func paramLocation(field reflect.StructField) (in, name string) {
+	for _, paramTag := range paramTags {
+		name = strings.Split(field.Tag.Get(paramTag.tag), ",")[0]
+		if name != "" && name != "-" {
+			return paramTag.in, name
+		}
+	}
+	return "", ""
+}
+
+// bindingRequired tells whether the binding tag has the required rule, the other rules like required_if don't make
+// the parameter required all the time.
+func bindingRequired(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		if strings.TrimSpace(rule) == DocTagFieldRequired {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldParam documents field as the parameter in, a slice is serialized as repeated query parameters like
+// ?ids=1&ids=2 or comma separated in a header or path, and a struct or map query parameter as deepObject like
+// ?filter[name]=a.
+func (s *Spec) fieldParam(prototype interface{}, field reflect.StructField, val reflect.Value, in, name string) *openapi3.ParameterRef {
+	schemaRef := s.valueSchema(val)
+	if schemaRef == nil {
+		panic(fmt.Sprintf("prototype=%+v field=%s can't be a parameter", prototype, field.Name))
+	}
+	if schemaRef.Ref == "" && val.IsZero() {
+		// a zero field of the prototype is not a meaningful example, it may not even match the pattern
+		schemaRef.Value.Example = nil
+	}
+	schemaRef, required := s.fieldSchema(schemaRef, prototype, field)
+	param := &openapi3.Parameter{
+		Name:     name,
+		In:       in,
+		Required: required || in == ParamPath || bindingRequired(field),
+		Schema:   schemaRef,
+	}
+	if schemaRef.Ref == "" {
+		// the description and deprecation belong to the parameter
+		param.Description, schemaRef.Value.Description = schemaRef.Value.Description, ""
+		param.Deprecated = schemaRef.Value.Deprecated
+	}
+	switch {
+	case schemaRef.Value.Type.Is(openapi3.TypeArray) && in == ParamQuery:
+		param.Style, param.Explode = openapi3.SerializationForm, openapi3.BoolPtr(true)
+	case schemaRef.Value.Type.Is(openapi3.TypeArray):
+		param.Style, param.Explode = openapi3.SerializationSimple, openapi3.BoolPtr(false)
+	case schemaRef.Value.Type.Is(openapi3.TypeObject) && in == ParamQuery:
+		param.Style, param.Explode = openapi3.SerializationDeepObject, openapi3.BoolPtr(true)
+	}
+	return &openapi3.ParameterRef{Value: param}
+}
@@ -0,0 +1,170 @@
+package ginx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type paramsPaging struct {
+	Limit int `form:"limit" doc:"minimum(1) maximum(100) desc(page size)"`
+}
+
+type paramsFilter struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+type listParams struct {
+	paramsPaging
+	OrgID   string       `uri:"org" doc:"pattern(^[a-z]+$)"`
+	TraceID string       `header:"X-Trace-Id" binding:"required"`
+	IDs     []int        `query:"ids" doc:"required maxItems(3)"`
+	Tags    []string     `header:"X-Tags"`
+	Filter  paramsFilter `form:"filter"`
+	Status  string       `form:"status" doc:"enum(active;closed) example(closed) deprecated"`
+	Cursor  string       `form:"cursor" binding:"required_without=Limit"`
+	Body    string       `json:"body"`
+	hidden  string       `form:"hidden"`
+}
+
+type paramsAPI struct {
+	spec *Spec
+}
+
+func (a paramsAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/orgs")
+	rg.
+		Get("/:%s/users", "org").
+		To(func(ctx *gin.Context) {
+			ctx.String(http.StatusOK, "success")
+		}).
+		Doc().
+		Params(listParams{paramsPaging: paramsPaging{Limit: 10}}).
+		Response("200", TextResponseBody("success"), "success")
+	return rg
+}
+
+func TestDocPathParams(t *testing.T) {
+	spec := NewSpec("params description", "1.0.0", "params")
+	g := gin.New()
+	spec.UseValidator(g, func(ctx *gin.Context, err error) {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+	})
+	assertNil(t, spec.AddAPI(g, paramsAPI{spec: spec}))
+
+	params := spec.Root().Paths.Value("/orgs/{org}/users").Get.Parameters
+	assertEqual(t, len(params), 8)
+	assertTrue(t, !params.GetByInAndName(ParamQuery, "cursor").Required)
+	limit := params.GetByInAndName(ParamQuery, "limit")
+	assertEqual(t, limit.Description, "page size")
+	assertEqual(t, limit.Schema.Value.Description, "")
+	assertEqual(t, *limit.Schema.Value.Max, float64(100))
+	assertEqual(t, limit.Schema.Value.Example, int64(10))
+	assertTrue(t, !limit.Required)
+	org := params.GetByInAndName(ParamPath, "org")
+	assertTrue(t, org.Required)
+	assertEqual(t, org.Schema.Value.Pattern, "^[a-z]+$")
+	assertTrue(t, params.GetByInAndName(ParamHeader, "X-Trace-Id").Required)
+	ids := params.GetByInAndName(ParamQuery, "ids")
+	assertTrue(t, ids.Required)
+	assertEqual(t, ids.Style, "form")
+	assertTrue(t, *ids.Explode)
+	tags := params.GetByInAndName(ParamHeader, "X-Tags")
+	assertEqual(t, tags.Style, "simple")
+	assertTrue(t, !*tags.Explode)
+	assertEqual(t, params.GetByInAndName(ParamQuery, "filter").Style, "deepObject")
+	assertTrue(t, params.GetByInAndName(ParamQuery, "status").Deprecated)
+	assertEqual(t, params.GetByInAndName(ParamQuery, "status").Schema.Value.Example, "closed")
+	assertNil(t, params.GetByInAndName(ParamQuery, "hidden"))
+	assertNil(t, spec.ValidateSpec(context.Background()))
+
+	serve := func(url string, headers map[string]string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		g.ServeHTTP(w, req)
+		return w.Code
+	}
+	headers := map[string]string{"X-Trace-Id": "trace", "X-Tags": "a,b"}
+	assertEqual(t, serve("/orgs/acme/users?ids=1&ids=2&filter[name]=a&filter[age]=1&status=active", headers), http.StatusOK)
+	assertEqual(t, serve("/orgs/acme/users?ids=1&ids=2&ids=3&ids=4", headers), http.StatusBadRequest)
+	assertEqual(t, serve("/orgs/acme/users?ids=a", headers), http.StatusBadRequest)
+	assertEqual(t, serve("/orgs/acme/users?ids=1&filter[age]=old", headers), http.StatusBadRequest)
+	assertEqual(t, serve("/orgs/acme/users?ids=1&status=open", headers), http.StatusBadRequest)
+	assertEqual(t, serve("/orgs/ACME/users?ids=1", headers), http.StatusBadRequest)
+	assertEqual(t, serve("/orgs/acme/users?ids=1", nil), http.StatusBadRequest)
+}
+
+type cookieParamsAPI struct {
+	spec *Spec
+}
+
+type cookieFilter struct {
+	Name string `json:"name" doc:"required"`
+}
+
+func (a cookieParamsAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/session")
+	rg.
+		Get("/items").
+		To(func(ctx *gin.Context) {
+			ctx.String(http.StatusOK, "success")
+		}).
+		Doc().
+		Cookie(Cookie("session").Schema("abc").Description("session id")).
+		Cookie(Cookie("csrf").Schema("token").Required(false).Deprecated(true)).
+		Query(Query("ids").Schema([]int{1}).Explode(false).Example([]int{1, 2})).
+		Query(Query("tags").Schema([]string{"a"}).Style("pipeDelimited").Explode(false).Required(false)).
+		Query(Query("q").Schema("q").AllowEmptyValue(true).Required(false)).
+		Query(Query("filter").Content("application/json", cookieFilter{Name: "a"}).Required(false)).
+		Response("200", TextResponseBody("success"), "success")
+	return rg
+}
+
+func TestDocParamSerialization(t *testing.T) {
+	spec := NewSpec("cookie description", "1.0.0", "cookie")
+	g := gin.New()
+	spec.UseValidator(g, func(ctx *gin.Context, err error) {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+	})
+	assertNil(t, spec.AddAPI(g, cookieParamsAPI{spec: spec}))
+
+	params := spec.Root().Paths.Value("/session/items").Get.Parameters
+	session := params.GetByInAndName(ParamCookie, "session")
+	assertTrue(t, session.Required)
+	assertEqual(t, session.Description, "session id")
+	assertTrue(t, params.GetByInAndName(ParamCookie, "csrf").Deprecated)
+	assertTrue(t, reflect.DeepEqual(params.GetByInAndName(ParamQuery, "ids").Example, []interface{}{float64(1), float64(2)}))
+	assertTrue(t, params.GetByInAndName(ParamQuery, "q").AllowEmptyValue)
+	filter := params.GetByInAndName(ParamQuery, "filter")
+	assertTrue(t, filter.Schema == nil)
+	assertNotNil(t, filter.Content.Get("application/json").Schema)
+	assertNil(t, spec.ValidateSpec(context.Background()))
+
+	serve := func(url string, cookies ...*http.Cookie) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		g.ServeHTTP(w, req)
+		return w.Code
+	}
+	session1 := &http.Cookie{Name: "session", Value: "abc"}
+	assertEqual(t, serve("/session/items?ids=1,2&tags=a|b&q=&filter=%7B%22name%22%3A%22a%22%7D", session1), http.StatusOK)
+	assertEqual(t, serve("/session/items?ids=1,2"), http.StatusBadRequest)
+	assertEqual(t, serve("/session/items?ids=1,a", session1), http.StatusBadRequest)
+	assertEqual(t, serve("/session/items?ids=1&filter=%7B%7D", session1), http.StatusBadRequest)
+
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	Query("both").Schema(1).Content("application/json", 1).ToOpenAPIParam(spec, ParamQuery)
+}
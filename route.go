@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"path"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,13 +14,23 @@ type API interface {
 }
 
 type route struct {
+	spec       *Spec
+	group      *RouteGroup
 	httpPath   string
 	httpMethod string
 	handlers   []gin.HandlerFunc
+	doc        *docPath
+
+	skipValidation bool
 }
 
 func NewRouteGroup(basePath string) *RouteGroup {
+	return DefaultSpec.NewRouteGroup(basePath)
+}
+
+func (s *Spec) NewRouteGroup(basePath string) *RouteGroup {
 	return &RouteGroup{
+		spec:     s,
 		basePath: basePath,
 	}
 }
@@ -29,17 +40,28 @@ func (r *route) To(handlerFunc ...gin.HandlerFunc) *route {
 	return r
 }
 
+// SkipValidation excludes the route from the request and response validators, the route is still documented.
+func (r *route) SkipValidation() *route {
+	r.skipValidation = true
+	return r
+}
+
 func (r *route) Doc() *docPath {
-	return newDocPath(r)
+	r.doc = newDocPath(r)
+	return r.doc
 }
 
 type RouteGroup struct {
+	spec     *Spec
 	basePath string
 	routes   []*route
+	security *openapi3.SecurityRequirements
 }
 
 func (rg *RouteGroup) add(httpPath, method string) *route {
 	r := &route{
+		spec:       rg.spec,
+		group:      rg,
 		httpPath:   httpPath,
 		httpMethod: method,
 	}
@@ -0,0 +1,158 @@
+package ginx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+var openAPIToGinPathPattern = regexp.MustCompile(`/\{([^/}]+)\}`)
+
+// docRouter finds the documented operation of a request for a validator. It is built eagerly by AddAPI, or when the
+// validator is added to a spec which already has paths, and it is rebuilt whenever AddAPI changes the document.
+type docRouter struct {
+	spec *Spec
+	opts []openapi3.ValidationOption
+
+	mu    sync.RWMutex
+	built bool
+	table *routeTable
+	err   error
+}
+
+// routeTable maps the routes of gin, keyed by the method and c.FullPath(), to the documented operations, so that
+// the operation is resolved from what gin has dispatched instead of matching the path again.
+type routeTable struct {
+	routes map[string]*routers.Route
+	paths  map[string]bool
+}
+
+func (s *Spec) newDocRouter(opts ...openapi3.ValidationOption) *docRouter {
+	r := &docRouter{spec: s, opts: opts}
+	s.routers = append(s.routers, r)
+	if s.root != nil && s.root.Paths.Len() > 0 {
+		if err := r.build(); err != nil {
+			panic(fmt.Sprintf("validator can't be added to an invalid document, err=%v", err))
+		}
+	}
+	return r
+}
+
+func (r *docRouter) get() (*routeTable, error) {
+	r.mu.RLock()
+	if r.built {
+		defer r.mu.RUnlock()
+		return r.table, r.err
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.built {
+		r.buildLocked()
+		if r.err != nil {
+			// the validators respond 500 to every request until the document is fixed
+			_, _ = fmt.Fprintf(gin.DefaultErrorWriter, "[GINX] invalid document, err=%v\n", r.err)
+		}
+	}
+	return r.table, r.err
+}
+
+func (r *docRouter) build() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buildLocked()
+	return r.err
+}
+
+func (r *docRouter) buildLocked() {
+	r.table, r.err = newRouteTable(r.spec.root, r.opts...)
+	r.built = true
+}
+
+func (r *docRouter) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.built, r.table, r.err = false, nil, nil
+}
+
+func newRouteTable(doc *openapi3.T, opts ...openapi3.ValidationOption) (*routeTable, error) {
+	if err := doc.Validate(context.Background(), opts...); err != nil {
+		return nil, err
+	}
+	t := &routeTable{
+		routes: make(map[string]*routers.Route),
+		paths:  make(map[string]bool),
+	}
+	for p, pathItem := range doc.Paths.Map() {
+		for method, operation := range pathItem.Operations() {
+			wildcard := wildcardParam(pathItem, operation)
+			ginPath := openAPIToGinPathPattern.ReplaceAllStringFunc(p, func(segment string) string {
+				name := segment[2 : len(segment)-1]
+				if name == wildcard {
+					return "/*" + name
+				}
+				return "/:" + name
+			})
+			t.paths[ginPath] = true
+			t.routes[routeKey(method, ginPath)] = &routers.Route{
+				Spec:      doc,
+				Path:      p,
+				PathItem:  pathItem,
+				Method:    method,
+				Operation: operation,
+			}
+		}
+	}
+	return t, nil
+}
+
+// find returns routers.ErrPathNotFound when gin hasn't matched the request or the path is not documented, and
+// routers.ErrMethodNotAllowed when only the other methods of the path are documented.
+func (t *routeTable) find(c *gin.Context) (*routers.Route, map[string]string, error) {
+	route, exists := t.routes[routeKey(c.Request.Method, c.FullPath())]
+	if !exists {
+		if c.FullPath() != "" && t.paths[c.FullPath()] {
+			return nil, nil, routers.ErrMethodNotAllowed
+		}
+		return nil, nil, routers.ErrPathNotFound
+	}
+	pathParams := make(map[string]string, len(c.Params))
+	for _, param := range c.Params {
+		pathParams[param.Key] = param.Value
+	}
+	return route, pathParams, nil
+}
+
+func wildcardParam(pathItem *openapi3.PathItem, operation *openapi3.Operation) string {
+	for _, params := range []openapi3.Parameters{pathItem.Parameters, operation.Parameters} {
+		for _, p := range params {
+			if p.Value != nil && p.Value.In == ParamPath && p.Value.Extensions[ExtensionWildcard] == true {
+				return p.Value.Name
+			}
+		}
+	}
+	return ""
+}
+
+func (s *Spec) buildRouters() error {
+	var errs []error
+	for _, r := range s.routers {
+		if err := r.build(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Spec) resetRouters() {
+	for _, r := range s.routers {
+		r.reset()
+	}
+}
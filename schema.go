@@ -0,0 +1,108 @@
+package ginx
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// OpenAPISchemaer is implemented by a type which knows its own schema, e.g. a type with a custom JSON marshaler,
+// the schema is used instead of the one reflected from the Go structure.
+type OpenAPISchemaer interface {
+	OpenAPISchema() *openapi3.Schema
+}
+
+var openAPISchemaerType = reflect.TypeOf((*OpenAPISchemaer)(nil)).Elem()
+
+func RegisterTypeSchema(t reflect.Type, schema *openapi3.Schema) {
+	DefaultSpec.RegisterTypeSchema(t, schema)
+}
+
+// RegisterTypeSchema documents the type t, and the pointers to it, by schema, which is meant for the third party types
+// which can't implement OpenAPISchemaer.
+func (s *Spec) RegisterTypeSchema(t reflect.Type, schema *openapi3.Schema) {
+	if t == nil || schema == nil {
+		panic("type and schema must not be nil")
+	}
+	if _, exists := s.typeSchemas[t]; exists {
+		panic(fmt.Sprintf("schema of type=%s already exists", t))
+	}
+	if s.typeSchemas == nil {
+		s.typeSchemas = make(map[reflect.Type]*openapi3.Schema)
+	}
+	s.typeSchemas[t] = schema
+}
+
+// customSchema returns a copy of the registered or self described schema of the type of prototype, so that the doc
+// tags of a field don't change it. The prototype is the example when the schema has none.
+func (s *Spec) customSchema(prototype interface{}) *openapi3.Schema {
+	t := derefType(reflect.TypeOf(prototype))
+	var custom *openapi3.Schema
+	if registered, exists := s.typeSchemas[t]; exists {
+		custom = registered
+	} else if t.Implements(openAPISchemaerType) {
+		custom = reflect.Zero(t).Interface().(OpenAPISchemaer).OpenAPISchema()
+	} else if reflect.PointerTo(t).Implements(openAPISchemaerType) {
+		custom = reflect.New(t).Interface().(OpenAPISchemaer).OpenAPISchema()
+	}
+	if custom == nil {
+		return nil
+	}
+	sch := *custom
+	if v := reflect.Indirect(reflect.ValueOf(prototype)); sch.Example == nil && v.IsValid() && !v.IsZero() {
+		sch.Example = jsonValue(prototype)
+	}
+	return &sch
+}
+
+func SetStrictRequired(strict bool) {
+	DefaultSpec.SetStrictRequired(strict)
+}
+
+// SetStrictRequired makes every field which is neither a pointer nor omitempty required, in addition to the fields
+// with the required doc tag. It must be called before the APIs are added, the components are built once.
+func (s *Spec) SetStrictRequired(strict bool) {
+	s.strictRequired = strict
+}
+
+// jsonFieldName returns the name and the options of the json tag, the name falls back to the form tag for the
+// form bodies. ok is false when the field is not encoded.
+func jsonFieldName(field reflect.StructField) (name string, opts map[string]bool, ok bool) {
+	tag := field.Tag.Get("json")
+	items := strings.Split(tag, ",")
+	name = items[0]
+	opts = make(map[string]bool)
+	for _, opt := range items[1:] {
+		opts[opt] = true
+	}
+	if tag == "-" {
+		name = ""
+	}
+	if name == "" {
+		if formName := strings.Split(field.Tag.Get("form"), ",")[0]; formName != "" && formName != "-" {
+			return formName, opts, true
+		}
+	}
+	return name, opts, tag != "-"
+}
+
+func isStringOptionKind(k reflect.Kind) bool {
+	return k == reflect.String || k == reflect.Bool || isNumberKind(k)
+}
+
+// stringOptionSchema documents a field with the ,string option, which encoding/json quotes into a JSON string.
+func stringOptionSchema(v reflect.Value) *openapi3.SchemaRef {
+	sch := openapi3.NewStringSchema()
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Ptr && v.CanInterface() {
+		if b, err := json.Marshal(v.Interface()); err == nil {
+			sch.Example = string(b)
+		}
+	}
+	return openapi3.NewSchemaRef("", sch)
+}
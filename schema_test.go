@@ -0,0 +1,110 @@
+package ginx
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+type money int64
+
+func (m money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("%d.%02d", m/100, m%100))
+}
+
+func (m money) OpenAPISchema() *openapi3.Schema {
+	sch := openapi3.NewStringSchema()
+	sch.Pattern = `^\d+\.\d{2}$`
+	return sch
+}
+
+type accountID struct {
+	value string
+}
+
+func (id *accountID) OpenAPISchema() *openapi3.Schema {
+	return openapi3.NewStringSchema().WithFormat("uuid")
+}
+
+func TestExtractSchemaCustomSchema(t *testing.T) {
+	spec := NewSpec("custom schema description", "1.0.0", "custom schema")
+	nullString := openapi3.NewStringSchema()
+	nullString.Nullable = true
+	spec.RegisterTypeSchema(reflect.TypeOf(sql.NullString{}), nullString)
+
+	sch := spec.extractSchema(struct {
+		Amount   money          `json:"amount" doc:"required desc(amount)"`
+		Fee      *money         `json:"fee" doc:"desc(fee)"`
+		Account  accountID      `json:"account" doc:"desc(account)"`
+		Nickname sql.NullString `json:"nickname" doc:"desc(nickname)"`
+	}{Amount: 1234}).Value
+	props := sch.Properties
+
+	assertTrue(t, props["amount"].Value.Type.Is("string"))
+	assertEqual(t, props["amount"].Value.Pattern, `^\d+\.\d{2}$`)
+	assertEqual(t, props["amount"].Value.Example, "12.34")
+	assertEqual(t, props["amount"].Value.Description, "amount")
+	assertTrue(t, props["fee"].Value.Type.Is("string"))
+	assertEqual(t, props["fee"].Value.Example, nil)
+	assertEqual(t, props["account"].Value.Format, "uuid")
+	assertTrue(t, props["nickname"].Value.Nullable)
+	assertEqual(t, props["nickname"].Value.Description, "nickname")
+	assertEqual(t, nullString.Description, "")
+	assertNil(t, sch.VisitJSON(map[string]interface{}{"amount": "1.00", "nickname": nil}))
+	assertNotNil(t, sch.VisitJSON(map[string]interface{}{"amount": 1}))
+	assertNil(t, spec.Root().Validate(context.Background()))
+
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	spec.RegisterTypeSchema(reflect.TypeOf(sql.NullString{}), nullString)
+}
+
+type jsonTagsBase struct {
+	ID string `json:"id"`
+}
+
+type jsonTagsStruct struct {
+	jsonTagsBase
+	Audit    jsonTagsBase `json:"audit"`
+	Name     string
+	Nickname *string `json:"nickname"`
+	Bio      string  `json:"bio,omitempty" doc:"desc(bio)"`
+	Balance  int64   `json:"balance,string"`
+	Limit    *int    `json:"limit,omitempty,string"`
+	Ignored  string  `json:"-"`
+	Dash     string  `json:"-,"`
+	Label    string  `json:"label" doc:"required"`
+	password string
+}
+
+func TestExtractSchemaJSONTags(t *testing.T) {
+	spec := NewSpec("json tags description", "1.0.0", "json tags")
+	sch := spec.extractSchema(struct {
+		Value jsonTagsStruct `json:"value"`
+	}{Value: jsonTagsStruct{Balance: 100}}).Value.Properties["value"].Value
+	props := sch.Properties
+
+	names := sortedKeys(props)
+	assertTrue(t, reflect.DeepEqual(names, []string{"-", "Name", "audit", "balance", "bio", "id", "label", "limit", "nickname"}))
+	assertEqual(t, props["audit"].Ref, "#/components/schemas/jsonTagsBase")
+	assertEqual(t, props["bio"].Value.Description, "bio")
+	assertTrue(t, props["balance"].Value.Type.Is("string"))
+	assertEqual(t, props["balance"].Value.Example, "100")
+	assertTrue(t, props["limit"].Value.Type.Is("string"))
+	assertTrue(t, reflect.DeepEqual(sch.Required, []string{"label"}))
+
+	strict := NewSpec("json tags description", "1.0.0", "json tags")
+	strict.SetStrictRequired(true)
+	sch = strict.extractSchema(jsonTagsStruct{}).Value
+	assertTrue(t, reflect.DeepEqual(sch.Required, []string{"id", "audit", "Name", "balance", "-", "label"}))
+	assertNil(t, sch.VisitJSON(map[string]interface{}{
+		"id": "1", "audit": map[string]interface{}{"id": "2"}, "Name": "name", "balance": "1", "-": "", "label": "label",
+	}))
+	assertNotNil(t, sch.VisitJSON(map[string]interface{}{"id": "1"}))
+}
@@ -0,0 +1,155 @@
+package ginx
+
+import (
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+type docSecurityScheme struct {
+	scheme *openapi3.SecurityScheme
+}
+
+func HTTPBearer(bearerFormat string) *docSecurityScheme {
+	return &docSecurityScheme{scheme: openapi3.NewSecurityScheme().WithType("http").WithScheme("bearer").WithBearerFormat(bearerFormat)}
+}
+
+func HTTPBasic() *docSecurityScheme {
+	return &docSecurityScheme{scheme: openapi3.NewSecurityScheme().WithType("http").WithScheme("basic")}
+}
+
+// APIKey declares an API key passed by the header, query or cookie named name.
+func APIKey(in, name string) *docSecurityScheme {
+	return &docSecurityScheme{scheme: openapi3.NewSecurityScheme().WithType("apiKey").WithIn(in).WithName(name)}
+}
+
+// OAuth2 declares an OAuth2 scheme, at least one flow must be added to it.
+func OAuth2() *docSecurityScheme {
+	return &docSecurityScheme{scheme: &openapi3.SecurityScheme{Type: "oauth2", Flows: &openapi3.OAuthFlows{}}}
+}
+
+func OpenIDConnect(url string) *docSecurityScheme {
+	return &docSecurityScheme{scheme: openapi3.NewOIDCSecurityScheme(url)}
+}
+
+func (d *docSecurityScheme) Description(desc string) *docSecurityScheme {
+	d.scheme.Description = desc
+	return d
+}
+
+func (d *docSecurityScheme) ImplicitFlow(authorizationURL string, scopes map[string]string) *docSecurityScheme {
+	d.flows().Implicit = &openapi3.OAuthFlow{AuthorizationURL: authorizationURL, Scopes: oauthScopes(scopes)}
+	return d
+}
+
+func (d *docSecurityScheme) PasswordFlow(tokenURL string, scopes map[string]string) *docSecurityScheme {
+	d.flows().Password = &openapi3.OAuthFlow{TokenURL: tokenURL, Scopes: oauthScopes(scopes)}
+	return d
+}
+
+func (d *docSecurityScheme) ClientCredentialsFlow(tokenURL string, scopes map[string]string) *docSecurityScheme {
+	d.flows().ClientCredentials = &openapi3.OAuthFlow{TokenURL: tokenURL, Scopes: oauthScopes(scopes)}
+	return d
+}
+
+func (d *docSecurityScheme) AuthorizationCodeFlow(authorizationURL, tokenURL string, scopes map[string]string) *docSecurityScheme {
+	d.flows().AuthorizationCode = &openapi3.OAuthFlow{AuthorizationURL: authorizationURL, TokenURL: tokenURL, Scopes: oauthScopes(scopes)}
+	return d
+}
+
+func (d *docSecurityScheme) flows() *openapi3.OAuthFlows {
+	if d.scheme.Type != "oauth2" {
+		panic(fmt.Sprintf("security scheme type=%s can't have flows", d.scheme.Type))
+	}
+	return d.scheme.Flows
+}
+
+func (d *docSecurityScheme) ToOpenAPISecurityScheme() *openapi3.SecuritySchemeRef {
+	return &openapi3.SecuritySchemeRef{
+		Ref:   "",
+		Value: d.scheme,
+	}
+}
+
+func oauthScopes(scopes map[string]string) map[string]string {
+	if scopes == nil {
+		// scopes is required, so it must be encoded as an empty object instead of null
+		return map[string]string{}
+	}
+	return scopes
+}
+
+func DefineSecurityScheme(name string, scheme *docSecurityScheme) {
+	DefaultSpec.DefineSecurityScheme(name, scheme)
+}
+
+// DefineSecurityScheme adds the scheme under components/securitySchemes.
+func (s *Spec) DefineSecurityScheme(name string, scheme *docSecurityScheme) {
+	if _, exists := s.securitySchemes[name]; exists {
+		panic(fmt.Sprintf("security scheme=%s already exists", name))
+	}
+	if s.securitySchemes == nil {
+		s.securitySchemes = make(openapi3.SecuritySchemes)
+	}
+	s.securitySchemes[name] = scheme.ToOpenAPISecurityScheme()
+	s.attachSecuritySchemes()
+}
+
+func (s *Spec) attachSecuritySchemes() {
+	if s.root == nil || len(s.securitySchemes) == 0 {
+		return
+	}
+	if s.root.Components == nil {
+		s.root.Components = &openapi3.Components{}
+	}
+	s.root.Components.SecuritySchemes = s.securitySchemes
+}
+
+func (s *Spec) mustHaveSecurityScheme(name string) {
+	if _, exists := s.securitySchemes[name]; !exists {
+		panic(fmt.Sprintf("security scheme=%s is not defined, must call DefineSecurityScheme first", name))
+	}
+}
+
+// Security adds name with scopes as an alternative security requirement of the operation, which replaces the
+// default security of the route group.
+func (d *docPath) Security(name string, scopes ...string) *docPath {
+	d.spec.mustHaveSecurityScheme(name)
+	if !d.hasSecurity {
+		d.operation.Security = openapi3.NewSecurityRequirements()
+		d.hasSecurity = true
+	}
+	d.operation.Security.With(openapi3.NewSecurityRequirement().Authenticate(name, scopes...))
+	return d
+}
+
+// NoSecurity marks the operation as public, which overrides the default security of the route group.
+func (d *docPath) NoSecurity() *docPath {
+	d.operation.Security = openapi3.NewSecurityRequirements()
+	d.hasSecurity = true
+	return d
+}
+
+// Security adds name with scopes as an alternative default security requirement of the routes in the group,
+// including the routes documented before.
+func (rg *RouteGroup) Security(name string, scopes ...string) *RouteGroup {
+	rg.spec.mustHaveSecurityScheme(name)
+	if rg.security == nil {
+		rg.security = openapi3.NewSecurityRequirements()
+	}
+	rg.security.With(openapi3.NewSecurityRequirement().Authenticate(name, scopes...))
+	for _, r := range rg.routes {
+		if r.doc != nil && !r.doc.hasSecurity {
+			r.doc.operation.Security = rg.defaultSecurity()
+		}
+	}
+	return rg
+}
+
+func (rg *RouteGroup) defaultSecurity() *openapi3.SecurityRequirements {
+	if rg.security == nil {
+		return nil
+	}
+	security := append(openapi3.SecurityRequirements{}, *rg.security...)
+	return &security
+}
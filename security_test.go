@@ -0,0 +1,145 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
+)
+
+type securityAPI struct {
+	spec *Spec
+}
+
+func (a securityAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/secure").Security("bearer")
+	handler := func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "success")
+	}
+	rg.Get("/default").To(handler).Doc().
+		Response("200", TextResponseBody("success"), "success")
+	rg.Get("/scoped").To(handler).Doc().
+		Security("oauth", "read", "write").
+		Security("apiKey").
+		Response("200", TextResponseBody("success"), "success")
+	rg.Get("/public").To(handler).Doc().
+		NoSecurity().
+		Response("200", TextResponseBody("success"), "success")
+	return rg
+}
+
+func TestSpec_DefineSecurityScheme(t *testing.T) {
+	spec := NewSpec("security description", "1.0.0", "security")
+	spec.DefineSecurityScheme("bearer", HTTPBearer("JWT").Description("JWT token"))
+	spec.DefineSecurityScheme("basic", HTTPBasic())
+	spec.DefineSecurityScheme("apiKey", APIKey(ParamHeader, "X-API-Key"))
+	spec.DefineSecurityScheme("oauth", OAuth2().
+		AuthorizationCodeFlow("https://example.com/auth", "https://example.com/token", map[string]string{
+			"read":  "read access",
+			"write": "write access",
+		}).
+		ClientCredentialsFlow("https://example.com/token", nil))
+	spec.DefineSecurityScheme("oidc", OpenIDConnect("https://example.com/.well-known/openid-configuration"))
+
+	g := gin.New()
+	spec.UseValidator(g, func(ctx *gin.Context, err error) {
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	})
+	spec.AddAPI(g, securityAPI{spec: spec})
+	assertNil(t, spec.Root().Validate(context.Background()))
+
+	schemes := spec.Root().Components.SecuritySchemes
+	assertEqual(t, len(schemes), 5)
+	assertEqual(t, schemes["bearer"].Value.BearerFormat, "JWT")
+	assertEqual(t, schemes["apiKey"].Value.In, "header")
+	assertEqual(t, len(schemes["oauth"].Value.Flows.AuthorizationCode.Scopes), 2)
+
+	b, err := json.Marshal(spec.Root().Paths.Value("/secure/default").Get)
+	assertNil(t, err)
+	assertTrue(t, strings.Contains(string(b), `"security":[{"bearer":[]}]`))
+	b, err = json.Marshal(spec.Root().Paths.Value("/secure/scoped").Get)
+	assertNil(t, err)
+	assertTrue(t, strings.Contains(string(b), `"security":[{"oauth":["read","write"]},{"apiKey":[]}]`))
+	b, err = json.Marshal(spec.Root().Paths.Value("/secure/public").Get)
+	assertNil(t, err)
+	assertTrue(t, strings.Contains(string(b), `"security":[]`))
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/secure/public", nil))
+	assertEqual(t, w.Code, http.StatusOK)
+}
+
+type lateSecurityAPI struct {
+	spec *Spec
+}
+
+func (a lateSecurityAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/late")
+	handler := func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "success")
+	}
+	rg.Get("/default").To(handler).Doc().
+		Response("200", TextResponseBody("success"), "success")
+	rg.Get("/public").To(handler).Doc().
+		NoSecurity().
+		Response("200", TextResponseBody("success"), "success")
+	rg.Security("bearer")
+	return rg
+}
+
+func TestRouteGroup_SecurityAfterDoc(t *testing.T) {
+	spec := NewSpec("security description", "1.0.0", "security")
+	spec.DefineSecurityScheme("bearer", HTTPBearer("JWT"))
+	g := gin.New()
+	spec.UseValidatorWithOptions(g, func(ctx *gin.Context, err error) {
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+		}
+	}, &ValidatorOptions{
+		Authenticators: map[string]Authenticator{
+			"bearer": func(c *gin.Context, input *openapi3filter.AuthenticationInput) (interface{}, error) {
+				return nil, errors.New("invalid token")
+			},
+		},
+	})
+	spec.AddAPI(g, lateSecurityAPI{spec: spec})
+
+	b, err := json.Marshal(spec.Root().Paths.Value("/late/default").Get)
+	assertNil(t, err)
+	assertTrue(t, strings.Contains(string(b), `"security":[{"bearer":[]}]`))
+	b, err = json.Marshal(spec.Root().Paths.Value("/late/public").Get)
+	assertNil(t, err)
+	assertTrue(t, strings.Contains(string(b), `"security":[]`))
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/late/default", nil))
+	assertEqual(t, w.Code, http.StatusUnauthorized)
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/late/public", nil))
+	assertEqual(t, w.Code, http.StatusOK)
+}
+
+func TestSpec_SecuritySchemeNotDefined(t *testing.T) {
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	NewSpec("", "1.0.0", "").NewRouteGroup("/secure").Security("bearer")
+}
+
+func TestDefineSecuritySchemeBeforeInit(t *testing.T) {
+	spec := &Spec{refs: make(map[string]interface{})}
+	spec.DefineSecurityScheme("bearer", HTTPBearer("JWT"))
+	spec.root = newDocRoot("", "1.0.0", "")
+	spec.attachSecuritySchemes()
+	assertNotNil(t, spec.Root().Components.SecuritySchemes["bearer"])
+}
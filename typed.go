@@ -0,0 +1,256 @@
+package ginx
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// TypedHandler is created by Typed and registered by route.Handle.
+type TypedHandler interface {
+	handlerFunc(s *Spec) gin.HandlerFunc
+	document(d *docPath, method string)
+}
+
+// BindingError is passed to the typed error handler when the request cannot be bound.
+type BindingError struct {
+	Err error
+}
+
+func (e *BindingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BindingError) Unwrap() error {
+	return e.Err
+}
+
+// TypedErrorResponse is the body rendered by DefaultTypedErrorHandler.
+type TypedErrorResponse struct {
+	Error string `json:"error" doc:"required desc(error message)"`
+}
+
+// DefaultTypedErrorHandler responds 400 to a BindingError and 500 to any other error.
+func DefaultTypedErrorHandler(c *gin.Context, err error) {
+	_ = c.Error(err)
+	var bindingErr *BindingError
+	if errors.As(err, &bindingErr) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, TypedErrorResponse{Error: err.Error()})
+	} else {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, TypedErrorResponse{Error: http.StatusText(http.StatusInternalServerError)})
+	}
+}
+
+func SetTypedErrorHandler(typedErrorHandler func(*gin.Context, error)) {
+	DefaultSpec.SetTypedErrorHandler(typedErrorHandler)
+}
+
+func (s *Spec) SetTypedErrorHandler(typedErrorHandler func(*gin.Context, error)) {
+	s.typedErrorHandler = typedErrorHandler
+}
+
+type typedHandler[Req, Resp any] struct {
+	fn func(*gin.Context, Req) (Resp, error)
+}
+
+// Typed adapts fn to a gin handler, the path (uri tag), header (header tag), query (form tag) and body of the
+// request are bound into Req, and Resp is rendered as JSON with 200.
+func Typed[Req, Resp any](fn func(*gin.Context, Req) (Resp, error)) TypedHandler {
+	return &typedHandler[Req, Resp]{fn: fn}
+}
+
+func (h *typedHandler[Req, Resp]) handlerFunc(s *Spec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		errorHandler := s.typedErrorHandler
+		if errorHandler == nil {
+			errorHandler = DefaultTypedErrorHandler
+		}
+		var req Req
+		if err := bindTyped(c, &req); err != nil {
+			errorHandler(c, &BindingError{Err: err})
+			return
+		}
+		resp, err := h.fn(c, req)
+		if err != nil {
+			errorHandler(c, err)
+			return
+		}
+		if !c.Writer.Written() {
+			c.JSON(http.StatusOK, resp)
+		}
+	}
+}
+
+func (h *typedHandler[Req, Resp]) document(d *docPath, method string) {
+	reqType := derefType(reflect.TypeOf((*Req)(nil)).Elem())
+	respType := derefType(reflect.TypeOf((*Resp)(nil)).Elem())
+
+	hasBody := method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+	formBody := hasBody && reqType.Kind() == reflect.Struct && !hasTaggedField(reqType, "json") && hasTaggedField(reqType, "form")
+	// any other shape is bound from JSON, e.g. a slice or a struct with untagged fields, unless all of its fields are
+	// parameters
+	bodyType := reqType
+	if reqType.Kind() == reflect.Struct {
+		bodyType = typedBodyType(reqType)
+	}
+	jsonBody := hasBody && !formBody && (bodyType.Kind() != reflect.Struct || bodyType.NumField() > 0)
+	documentTypedParams(d, reqType, !formBody)
+
+	// the zero values only describe the schema, they are not meaningful examples
+	if jsonBody {
+		d.RequestBody(&docRequestBody{
+			required:  true,
+			mediaType: "application/json",
+			contents:  map[string]*docContent{"application/json": {prototype: reflect.Zero(bodyType).Interface(), zero: true}},
+		})
+	} else if formBody {
+		d.RequestBody(&docRequestBody{
+			required:  true,
+			mediaType: "application/x-www-form-urlencoded",
+			contents:  map[string]*docContent{"application/x-www-form-urlencoded": {prototype: reflect.Zero(reqType).Interface(), zero: true}},
+		})
+	}
+	if respType.Kind() != reflect.Interface {
+		d.Response("200", &docResponse{
+			mediaType: "application/json",
+			contents:  map[string]*docContent{"application/json": {prototype: reflect.Zero(respType).Interface(), zero: true}},
+		}, http.StatusText(http.StatusOK))
+	}
+	if d.spec.typedErrorHandler == nil {
+		d.Response("400", &docResponse{
+			mediaType: "application/json",
+			contents:  map[string]*docContent{"application/json": {prototype: TypedErrorResponse{}}},
+		}, "the request can't be bound")
+		d.Response("500", &docResponse{
+			mediaType: "application/json",
+			contents:  map[string]*docContent{"application/json": {prototype: TypedErrorResponse{}}},
+		}, http.StatusText(http.StatusInternalServerError))
+	} else {
+		// the responses of a custom error handler are unknown, any of them is accepted
+		d.Response("default", &docResponse{}, "error")
+	}
+}
+
+func (r *route) Handle(handler TypedHandler, middlewares ...gin.HandlerFunc) *docPath {
+	r.handlers = append(middlewares, handler.handlerFunc(r.spec))
+	d := r.Doc()
+	handler.document(d, r.httpMethod)
+	return d
+}
+
+func bindTyped(c *gin.Context, obj interface{}) error {
+	if len(c.Params) > 0 {
+		params := make(map[string][]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = []string{p.Value}
+		}
+		if err := binding.MapFormWithTag(obj, params, "uri"); err != nil {
+			return err
+		}
+	}
+	headers := make(map[string][]string)
+	walkTaggedFields(derefType(reflect.TypeOf(obj)), "header", func(name string, field reflect.StructField) {
+		headers[name] = c.Request.Header.Values(name)
+	})
+	if err := binding.MapFormWithTag(obj, headers, "header"); err != nil {
+		return err
+	}
+	if err := binding.MapFormWithTag(obj, c.Request.URL.Query(), "form"); err != nil {
+		return err
+	}
+	if c.Request.Body != nil && c.Request.Body != http.NoBody && c.Request.ContentLength != 0 {
+		return c.ShouldBind(obj)
+	}
+	if binding.Validator == nil {
+		return nil
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+func documentTypedParams(d *docPath, t reflect.Type, withQuery bool) {
+	prototype := reflect.Zero(t).Interface()
+	typedParam := func(in string) func(name string, field reflect.StructField) {
+		return func(name string, field reflect.StructField) {
+			d.addParam(d.spec.fieldParam(prototype, field, reflect.Zero(field.Type), in, name))
+		}
+	}
+	walkTaggedFields(t, "uri", typedParam(ParamPath))
+	walkTaggedFields(t, "header", typedParam(ParamHeader))
+	if withQuery {
+		walkTaggedFields(t, "form", func(name string, field reflect.StructField) {
+			if _, isJSON := field.Tag.Lookup("json"); !isJSON {
+				typedParam(ParamQuery)(name, field)
+			}
+		})
+	}
+}
+
+// typedBodyType returns t without the fields bound from the path, the headers or the query, they are documented as
+// parameters instead. t itself is returned when all of its fields belong to the body.
+func typedBodyType(t reflect.Type) reflect.Type {
+	var fields []reflect.StructField
+	names := make(map[string]bool)
+	bound := false
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous && derefType(field.Type).Kind() == reflect.Struct {
+				walk(derefType(field.Type))
+				continue
+			}
+			if _, isJSON := field.Tag.Lookup("json"); !isJSON && (field.Tag.Get("uri") != "" || field.Tag.Get("header") != "" || field.Tag.Get("form") != "") {
+				bound = true
+				continue
+			}
+			if field.PkgPath != "" || names[field.Name] {
+				continue
+			}
+			names[field.Name] = true
+			fields = append(fields, reflect.StructField{Name: field.Name, Type: field.Type, Tag: field.Tag})
+		}
+	}
+	walk(t)
+	if !bound {
+		return t
+	}
+	return reflect.StructOf(fields)
+}
+
+func hasTaggedField(t reflect.Type, tag string) bool {
+	found := false
+	walkTaggedFields(t, tag, func(string, reflect.StructField) {
+		found = true
+	})
+	return found
+}
+
+// walkTaggedFields visits the fields of the struct t, including the ones of embedded structs, which have the tag.
+func walkTaggedFields(t reflect.Type, tag string, visit func(name string, field reflect.StructField)) {
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && derefType(field.Type).Kind() == reflect.Struct {
+			walkTaggedFields(derefType(field.Type), tag, visit)
+			continue
+		}
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if len(name) == 0 || name == "-" {
+			continue
+		}
+		visit(name, field)
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,233 @@
+package ginx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type typedRequest struct {
+	ID      string   `uri:"id" doc:"desc(user id)"`
+	TraceID string   `header:"X-Trace-Id"`
+	Verbose bool     `form:"verbose"`
+	Name    string   `json:"name" binding:"required" doc:"required desc(user name)"`
+	Tags    []string `json:"tags" doc:"desc(user tags)"`
+}
+
+type typedResponse struct {
+	ID      string `json:"id" doc:"required"`
+	TraceID string `json:"traceId" doc:"desc(trace id)"`
+	Verbose bool   `json:"verbose" doc:"desc(verbose)"`
+	Name    string `json:"name" doc:"required"`
+}
+
+type typedAPI struct {
+	spec *Spec
+}
+
+func (a typedAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/typed")
+	rg.
+		Put("/users/:%s", "id").
+		Handle(Typed(func(ctx *gin.Context, req typedRequest) (typedResponse, error) {
+			if req.Name == "fail" {
+				return typedResponse{}, errors.New("failed")
+			}
+			return typedResponse{ID: req.ID, TraceID: req.TraceID, Verbose: req.Verbose, Name: req.Name}, nil
+		})).
+		Summary("typed test")
+	return rg
+}
+
+func TestTyped(t *testing.T) {
+	spec := NewSpec("typed description", "1.0.0", "typed")
+	g := gin.New()
+	spec.UseValidator(g, func(ctx *gin.Context, err error) {
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	})
+	spec.AddAPI(g, typedAPI{spec: spec})
+
+	op := spec.Root().Paths.Value("/typed/users/{id}").Put
+	assertNotNil(t, op)
+	assertEqual(t, op.Summary, "typed test")
+	assertEqual(t, len(op.Parameters), 3)
+	assertEqual(t, op.Parameters.GetByInAndName(ParamPath, "id").Description, "user id")
+	assertNotNil(t, op.Parameters.GetByInAndName(ParamHeader, "X-Trace-Id"))
+	assertNotNil(t, op.Parameters.GetByInAndName(ParamQuery, "verbose"))
+	body := op.RequestBody.Value.Content.Get("application/json").Schema.Value
+	assertEqual(t, len(body.Properties), 2)
+	assertTrue(t, body.Properties["tags"].Value.Type.Is("array"))
+	assertEqual(t, body.Required[0], "name")
+	assertNotNil(t, op.Responses.Status(http.StatusOK).Value.Content.Get("application/json").Schema.Value.Properties["traceId"])
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/typed/users/1?verbose=true", bytes.NewBufferString(`{"name": "test"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Trace-Id", "trace")
+	g.ServeHTTP(w, req)
+	assertEqual(t, w.Code, http.StatusOK)
+	var resp typedResponse
+	assertNil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assertEqual(t, resp, typedResponse{ID: "1", TraceID: "trace", Verbose: true, Name: "test"})
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPut, "/typed/users/1", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	g.ServeHTTP(w, req)
+	assertEqual(t, w.Code, http.StatusBadRequest)
+
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/typed/users/1?verbose=abc", nil))
+	assertEqual(t, w.Code, http.StatusBadRequest)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPut, "/typed/users/1", bytes.NewBufferString(`{"name": "fail"}`))
+	req.Header.Set("Content-Type", "application/json")
+	g.ServeHTTP(w, req)
+	assertEqual(t, w.Code, http.StatusInternalServerError)
+}
+
+func TestSpec_SetTypedErrorHandler(t *testing.T) {
+	spec := NewSpec("typed description", "1.0.0", "typed")
+	spec.SetTypedErrorHandler(func(ctx *gin.Context, err error) {
+		ctx.AbortWithStatus(http.StatusTeapot)
+	})
+	g := gin.New()
+	spec.AddAPI(g, typedAPI{spec: spec})
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/typed/users/1", nil))
+	assertEqual(t, w.Code, http.StatusTeapot)
+}
+
+type typedBodyAPI struct {
+	spec *Spec
+}
+
+type typedUntaggedRequest struct {
+	ID   string `uri:"id"`
+	Name string
+}
+
+func (a typedBodyAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/typed")
+	rg.
+		Post("/ids").
+		Handle(Typed(func(ctx *gin.Context, req []int) (int, error) {
+			return len(req), nil
+		}))
+	rg.
+		Put("/names/:%s", "id").
+		Handle(Typed(func(ctx *gin.Context, req typedUntaggedRequest) (string, error) {
+			if req.Name == "fail" {
+				return "", errors.New("failed")
+			}
+			return req.Name, nil
+		}))
+	return rg
+}
+
+func TestTypedBodyAndErrorResponses(t *testing.T) {
+	spec := NewSpec("typed description", "1.0.0", "typed")
+	g := gin.New()
+	var errs []error
+	spec.UseResponseValidator(g, ResponseValidationLog, func(ctx *gin.Context, err error) {
+		errs = append(errs, err)
+	})
+	spec.AddAPI(g, typedBodyAPI{spec: spec})
+
+	op := spec.Root().Paths.Value("/typed/ids").Post
+	assertTrue(t, op.RequestBody.Value.Content.Get("application/json").Schema.Value.Type.Is("array"))
+	assertNotNil(t, op.Responses.Status(http.StatusBadRequest).Value.Content.Get("application/json").Schema.Value.Properties["error"])
+	assertNotNil(t, op.Responses.Status(http.StatusInternalServerError))
+	op = spec.Root().Paths.Value("/typed/names/{id}").Put
+	body := op.RequestBody.Value.Content.Get("application/json").Schema.Value
+	assertEqual(t, len(body.Properties), 1)
+	assertNotNil(t, body.Properties["Name"])
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/typed/ids", bytes.NewBufferString(`[1, 2]`))
+	req.Header.Set("Content-Type", "application/json")
+	g.ServeHTTP(w, req)
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Body.String(), "2")
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/typed/ids", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	g.ServeHTTP(w, req)
+	assertEqual(t, w.Code, http.StatusBadRequest)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPut, "/typed/names/1", bytes.NewBufferString(`{"Name": "fail"}`))
+	req.Header.Set("Content-Type", "application/json")
+	g.ServeHTTP(w, req)
+	assertEqual(t, w.Code, http.StatusInternalServerError)
+	assertEqual(t, len(errs), 0)
+}
+
+func TestTypedCustomErrorResponses(t *testing.T) {
+	spec := NewSpec("typed description", "1.0.0", "typed")
+	spec.SetTypedErrorHandler(func(ctx *gin.Context, err error) {
+		ctx.AbortWithStatus(http.StatusTeapot)
+	})
+	g := gin.New()
+	spec.AddAPI(g, typedBodyAPI{spec: spec})
+
+	op := spec.Root().Paths.Value("/typed/ids").Post
+	assertNil(t, op.Responses.Status(http.StatusBadRequest))
+	assertNotNil(t, op.Responses.Default())
+}
+
+type typedConstrainedRequest struct {
+	Code  string `json:"code" doc:"required pattern(^[A-Z]+$)"`
+	Name  string `json:"name" doc:"minLength(1) example(alice)"`
+	Count int    `json:"count" doc:"minimum(1)"`
+}
+
+type typedConstrainedAPI struct {
+	spec *Spec
+}
+
+func (a typedConstrainedAPI) RouteGroup() *RouteGroup {
+	rg := a.spec.NewRouteGroup("/typed")
+	rg.
+		Post("/codes").
+		Handle(Typed(func(ctx *gin.Context, req typedConstrainedRequest) (typedConstrainedRequest, error) {
+			return req, nil
+		}))
+	return rg
+}
+
+func TestTypedConstrainedFields(t *testing.T) {
+	spec := NewSpec("typed description", "1.0.0", "typed")
+	g := gin.New()
+	spec.UseValidator(g, func(ctx *gin.Context, err error) {
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusBadRequest)
+		}
+	})
+	assertNil(t, spec.AddAPI(g, typedConstrainedAPI{spec: spec}))
+	assertNil(t, spec.ValidateSpec(context.Background()))
+
+	sch := spec.Root().Components.Schemas["typedConstrainedRequest"].Value
+	assertNil(t, sch.Properties["code"].Value.Example)
+	assertNil(t, sch.Properties["count"].Value.Example)
+	assertEqual(t, sch.Properties["name"].Value.Example, "alice")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/typed/codes", bytes.NewBufferString(`{"code": "AB", "count": 2}`))
+	req.Header.Set("Content-Type", "application/json")
+	g.ServeHTTP(w, req)
+	assertEqual(t, w.Code, http.StatusOK)
+}